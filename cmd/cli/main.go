@@ -0,0 +1,289 @@
+// Command cli is a command line client of shortener, it calls API of
+// service over gRPC or HTTP.
+//
+// Usage:
+//
+//	cli [flags] <command> [command flags] [arguments]
+//
+// Commands are shorten, batch, list, delete, expand and stats. Token
+// of user is passed by -token flag or TOKEN environment variable, if
+// server issues token for new user, it is printed to stderr.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/pkg/client"
+)
+
+// config is a configuration of CLI, that is set by flags.
+type config struct {
+	server    string
+	transport string
+	tls       bool
+	ca        string
+	cert      string
+	key       string
+	token     string
+	output    string
+	timeout   time.Duration
+}
+
+// command runs subcommand with client by its arguments.
+type command struct {
+	usage string
+	run   func(ctx context.Context, c client.Client, p *printer, args []string) error
+}
+
+var commands = map[string]command{
+	"shorten": {usage: "shorten [-title title] <url> - сокращение URL", run: shorten},
+	"batch":   {usage: "batch [-file path] - сокращение URL из файла или stdin, по одному URL или паре \"id URL\" в строке", run: batch},
+	"list":    {usage: "list [-page-size n] [-page-token token] [-all] - список URL пользователя", run: list},
+	"delete":  {usage: "delete <short url>... - удаление URL пользователя", run: remove},
+	"expand":  {usage: "expand <short url> - описание сокращённого URL", run: expand},
+	"stats":   {usage: "stats - статистика сервиса", run: stats},
+}
+
+func main() {
+	cfg := &config{}
+	flag.StringVar(&cfg.server, "server", "", "Адрес сервера, по умолчанию localhost:3200 для gRPC и localhost:8080 для HTTP")
+	flag.StringVar(&cfg.transport, "transport", "grpc", "Транспорт: grpc или http")
+	flag.BoolVar(&cfg.tls, "tls", false, "Подключение по TLS")
+	flag.StringVar(&cfg.ca, "ca", "", "Файл с сертификатами CA для проверки сервера")
+	flag.StringVar(&cfg.cert, "cert", "", "Файл с сертификатом клиента")
+	flag.StringVar(&cfg.key, "key", "", "Файл с ключом сертификата клиента")
+	flag.StringVar(&cfg.token, "token", os.Getenv("TOKEN"), "Токен пользователя")
+	flag.StringVar(&cfg.output, "output", "table", "Формат вывода: table или json")
+	flag.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "Время ожидания ответа")
+	flag.Usage = usage
+	flag.Parse()
+
+	if err := run(cfg, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs command of arguments.
+func run(cfg *config, args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errors.New("command is required")
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	p, err := newPrinter(os.Stdout, cfg.output)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ca != "" || cfg.cert != "" {
+		cfg.tls = true
+	}
+
+	clientConfig := client.Config{Address: cfg.server, Token: cfg.token, Timeout: cfg.timeout}
+	if cfg.tls {
+		clientConfig.TLS, err = client.TLSConfig(cfg.ca, cfg.cert, cfg.key)
+		if err != nil {
+			return err
+		}
+	}
+
+	var c client.Client
+	switch cfg.transport {
+	case "grpc":
+		if clientConfig.Address == "" {
+			clientConfig.Address = "localhost:3200"
+		}
+		c, err = client.NewGRPCClient(clientConfig)
+	case "http":
+		if clientConfig.Address == "" {
+			clientConfig.Address = "localhost:8080"
+		}
+		c = client.NewHTTPClient(clientConfig)
+	default:
+		return fmt.Errorf("unknown transport %q", cfg.transport)
+	}
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+
+	err = cmd.run(ctx, c, p, args[1:])
+	if c.Token() != "" && c.Token() != cfg.token {
+		fmt.Fprintln(os.Stderr, "token:", c.Token())
+	}
+
+	return err
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Использование: %s [флаги] <команда> [флаги команды] [аргументы]\n\nКоманды:\n", os.Args[0])
+	for _, name := range []string{"shorten", "batch", "list", "delete", "expand", "stats"} {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(flag.CommandLine.Output(), "\nФлаги:")
+	flag.PrintDefaults()
+}
+
+// shorten prints short URL of original URL. If original URL is already
+// shortened, existing short URL is printed and it is not an error.
+func shorten(ctx context.Context, c client.Client, p *printer, args []string) error {
+	fs := flag.NewFlagSet("shorten", flag.ContinueOnError)
+	title := fs.String("title", "", "Заголовок URL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("shorten: URL is required")
+	}
+
+	shortURL, err := c.PostURL(ctx, fs.Arg(0), client.Options{Title: *title})
+	if err != nil && (!errors.Is(err, client.ErrorConflict) || shortURL == "") {
+		return err
+	}
+
+	return p.shortURL(shortURL)
+}
+
+func batch(ctx context.Context, c client.Client, p *printer, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	file := fs.String("file", "-", "Файл с URL, \"-\" для stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	URLs, err := readBatch(r)
+	if err != nil {
+		return err
+	}
+	if len(URLs) == 0 {
+		return errors.New("batch: no URLs")
+	}
+
+	result, err := c.ShortenBatch(ctx, URLs)
+	if err != nil {
+		return err
+	}
+
+	return p.batch(result)
+}
+
+// readBatch reads URLs by lines, line is either URL or correlation ID
+// and URL, that are separated by space. Correlation ID of URL without
+// it is a number of line. Empty lines and lines, that start with "#",
+// are skipped.
+func readBatch(r io.Reader) ([]client.URL, error) {
+	var URLs []client.URL
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			URLs = append(URLs, client.URL{CorrelationID: strconv.Itoa(n), URL: fields[0]})
+		case 2:
+			URLs = append(URLs, client.URL{CorrelationID: fields[0], URL: fields[1]})
+		default:
+			return nil, fmt.Errorf("line %d: expected URL or correlation ID and URL", n)
+		}
+	}
+
+	return URLs, s.Err()
+}
+
+func list(ctx context.Context, c client.Client, p *printer, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	pageSize := fs.Int("page-size", 50, "Количество URL на странице")
+	pageToken := fs.String("page-token", "", "Токен страницы")
+	all := fs.Bool("all", false, "Вывод всех страниц")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !*all {
+		URLs, next, err := c.GetUserURLsPage(ctx, *pageToken, *pageSize)
+		if err != nil {
+			return err
+		}
+
+		return p.list(URLs, next)
+	}
+
+	var URLs []client.URL
+	token := *pageToken
+	for {
+		page, next, err := c.GetUserURLsPage(ctx, token, *pageSize)
+		if err != nil {
+			return err
+		}
+
+		URLs = append(URLs, page...)
+		if next == "" {
+			return p.list(URLs, "")
+		}
+		token = next
+	}
+}
+
+func remove(ctx context.Context, c client.Client, p *printer, args []string) error {
+	if len(args) == 0 {
+		return errors.New("delete: short URLs are required")
+	}
+
+	return c.DeleteUserURLs(ctx, args)
+}
+
+func expand(ctx context.Context, c client.Client, p *printer, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expand: short URL is required")
+	}
+
+	e, err := c.ExpandURL(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	return p.expansion(e)
+}
+
+func stats(ctx context.Context, c client.Client, p *printer, args []string) error {
+	s, err := c.GetStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.stats(s)
+}
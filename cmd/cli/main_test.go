@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []client.URL
+		wantErr bool
+	}{
+		{
+			name:  "Test case #1",
+			input: "https://yandex.ru\n\n# comment\nid https://practicum.yandex.ru\n",
+			want: []client.URL{
+				{CorrelationID: "1", URL: "https://yandex.ru"},
+				{CorrelationID: "id", URL: "https://practicum.yandex.ru"},
+			},
+		},
+		{
+			name:  "Test case #2",
+			input: "  https://yandex.ru  \r\n",
+			want:  []client.URL{{CorrelationID: "1", URL: "https://yandex.ru"}},
+		},
+		{
+			name:  "Test case #3",
+			input: "",
+		},
+		{
+			name:    "Test case #4",
+			input:   "https://yandex.ru\nid https://yandex.ru extra\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBatch(strings.NewReader(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_shorten_list(t *testing.T) {
+	ctx := context.Background()
+	c := client.NewFake("http://localhost:8080")
+
+	var out bytes.Buffer
+	p, err := newPrinter(&out, "table")
+	require.NoError(t, err)
+
+	for _, u := range []string{"https://yandex.ru", "https://practicum.yandex.ru", "https://yandex.ru"} {
+		require.NoError(t, shorten(ctx, c, p, []string{"-title", "Yandex", u}))
+	}
+	assert.Equal(t, "http://localhost:8080/1\nhttp://localhost:8080/2\nhttp://localhost:8080/1\n", out.String())
+
+	assert.Error(t, shorten(ctx, c, p, []string{"yandex.ru"}))
+	assert.Error(t, shorten(ctx, c, p, nil))
+
+	out.Reset()
+	p, err = newPrinter(&out, "json")
+	require.NoError(t, err)
+	require.NoError(t, list(ctx, c, p, []string{"-page-size", "1", "-all"}))
+
+	var got struct {
+		URLs []struct {
+			ShortURL string `json:"short_url"`
+			URL      string `json:"original_url"`
+			Title    string `json:"title"`
+		} `json:"urls"`
+		NextPageToken string `json:"next_page_token"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Len(t, got.URLs, 2)
+	assert.Equal(t, "http://localhost:8080/1", got.URLs[0].ShortURL)
+	assert.Equal(t, "https://yandex.ru", got.URLs[0].URL)
+	assert.Equal(t, "Yandex", got.URLs[0].Title)
+	assert.Equal(t, "http://localhost:8080/2", got.URLs[1].ShortURL)
+	assert.Equal(t, "https://practicum.yandex.ru", got.URLs[1].URL)
+	assert.Empty(t, got.NextPageToken)
+
+	out.Reset()
+	require.NoError(t, list(ctx, c, p, []string{"-page-size", "1"}))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Len(t, got.URLs, 1)
+	assert.NotEmpty(t, got.NextPageToken)
+}
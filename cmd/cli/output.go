@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/pkg/client"
+)
+
+// printer prints results of commands as table or json.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "table":
+		return &printer{w: w}, nil
+	case "json":
+		return &printer{w: w, json: true}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+func (p *printer) shortURL(shortURL string) error {
+	if p.json {
+		return p.encode(struct {
+			ShortURL string `json:"result"`
+		}{shortURL})
+	}
+
+	_, err := fmt.Fprintln(p.w, shortURL)
+	return err
+}
+
+func (p *printer) batch(URLs []client.URL) error {
+	if p.json {
+		return p.encode(URLs)
+	}
+
+	return p.table([]string{"CORRELATION ID", "SHORT URL"}, len(URLs), func(i int) []string {
+		return []string{URLs[i].CorrelationID, URLs[i].ShortURL}
+	})
+}
+
+// list prints page of URLs, token of the next page is printed to
+// stderr in table, unless it is empty.
+func (p *printer) list(URLs []client.URL, next string) error {
+	if p.json {
+		type item struct {
+			client.URL
+			CreatedAt *time.Time `json:"created_at,omitempty"`
+		}
+
+		items := make([]item, 0, len(URLs))
+		for _, u := range URLs {
+			i := item{URL: u}
+			if !u.CreatedAt.IsZero() {
+				i.CreatedAt = &u.CreatedAt
+			}
+			items = append(items, i)
+		}
+
+		return p.encode(struct {
+			URLs          []item `json:"urls"`
+			NextPageToken string `json:"next_page_token,omitempty"`
+		}{items, next})
+	}
+
+	err := p.table([]string{"SHORT URL", "ORIGINAL URL", "TITLE", "CREATED", "FLAGS"}, len(URLs), func(i int) []string {
+		return []string{URLs[i].ShortURL, URLs[i].URL, URLs[i].Title, formatTime(URLs[i].CreatedAt), strings.Join(URLs[i].Flags, ",")}
+	})
+	if err != nil {
+		return err
+	}
+
+	if next != "" {
+		fmt.Fprintln(os.Stderr, "next page token:", next)
+	}
+
+	return nil
+}
+
+func (p *printer) expansion(e *client.Expansion) error {
+	if p.json {
+		return p.encode(e)
+	}
+
+	var createdAt time.Time
+	if e.CreatedAt != nil {
+		createdAt = *e.CreatedAt
+	}
+
+	rows := [][]string{
+		{"SHORT URL", e.ShortURL},
+		{"STATE", string(e.State)},
+		{"ORIGINAL URL", e.URL},
+		{"TITLE", e.Title},
+		{"CREATED", formatTime(createdAt)},
+		{"FLAGS", strings.Join(e.Flags, ",")},
+		{"REASON", e.Reason},
+	}
+
+	return p.table(nil, len(rows), func(i int) []string { return rows[i] })
+}
+
+func (p *printer) stats(s *client.Stats) error {
+	if p.json {
+		return p.encode(s)
+	}
+
+	rows := [][]string{
+		{"URLS", fmt.Sprint(s.URLs)},
+		{"USERS", fmt.Sprint(s.Users)},
+	}
+
+	return p.table(nil, len(rows), func(i int) []string { return rows[i] })
+}
+
+func (p *printer) encode(v interface{}) error {
+	e := json.NewEncoder(p.w)
+	e.SetIndent("", "  ")
+	return e.Encode(v)
+}
+
+// table prints header, if it is set, and n rows, that are returned by
+// row, aligned by columns.
+func (p *printer) table(header []string, n int, row func(int) []string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for i := 0; i < n; i++ {
+		fmt.Fprintln(tw, strings.Join(row(i), "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// formatTime formats time in RFC 3339, zero time is empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newPrinter(t *testing.T) {
+	_, err := newPrinter(&bytes.Buffer{}, "xml")
+	assert.Error(t, err)
+}
+
+func Test_printer(t *testing.T) {
+	createdAt := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format string
+		print  func(p *printer) error
+		want   string
+	}{
+		{
+			name:   "Test case #1",
+			format: "table",
+			print:  func(p *printer) error { return p.shortURL("http://localhost:8080/1") },
+			want:   "http://localhost:8080/1\n",
+		},
+		{
+			name:   "Test case #2",
+			format: "json",
+			print:  func(p *printer) error { return p.shortURL("http://localhost:8080/1") },
+			want:   "{\n  \"result\": \"http://localhost:8080/1\"\n}\n",
+		},
+		{
+			name:   "Test case #3",
+			format: "table",
+			print: func(p *printer) error {
+				return p.batch([]client.URL{{CorrelationID: "1", ShortURL: "http://localhost:8080/1"}})
+			},
+			want: "CORRELATION ID  SHORT URL\n1               http://localhost:8080/1\n",
+		},
+		{
+			name:   "Test case #4",
+			format: "table",
+			print: func(p *printer) error {
+				return p.list([]client.URL{{
+					ShortURL:  "http://localhost:8080/1",
+					URL:       "https://yandex.ru",
+					CreatedAt: createdAt,
+					Options:   client.Options{Title: "Yandex", Flags: []string{"homograph", "moderator"}},
+				}}, "")
+			},
+			want: "SHORT URL                ORIGINAL URL       TITLE   CREATED               FLAGS\n" +
+				"http://localhost:8080/1  https://yandex.ru  Yandex  2021-01-02T03:04:05Z  homograph,moderator\n",
+		},
+		{
+			name:   "Test case #5",
+			format: "json",
+			print: func(p *printer) error {
+				return p.list([]client.URL{{ShortURL: "http://localhost:8080/1", URL: "https://yandex.ru", CreatedAt: createdAt}}, "next")
+			},
+			want: "{\n  \"urls\": [\n    {\n      \"original_url\": \"https://yandex.ru\",\n      \"short_url\": \"http://localhost:8080/1\",\n" +
+				"      \"created_at\": \"2021-01-02T03:04:05Z\"\n    }\n  ],\n  \"next_page_token\": \"next\"\n}\n",
+		},
+		{
+			name:   "Test case #6",
+			format: "table",
+			print: func(p *printer) error {
+				return p.expansion(&client.Expansion{ShortURL: "http://localhost:8080/1", State: client.LinkDisabled, Reason: "phishing"})
+			},
+			want: "SHORT URL     http://localhost:8080/1\nSTATE         disabled\nORIGINAL URL  \nTITLE         \nCREATED       \nFLAGS         \nREASON        phishing\n",
+		},
+		{
+			name:   "Test case #7",
+			format: "json",
+			print: func(p *printer) error {
+				return p.expansion(&client.Expansion{ShortURL: "http://localhost:8080/1", URL: "https://yandex.ru", State: client.LinkActive})
+			},
+			want: "{\n  \"short_url\": \"http://localhost:8080/1\",\n  \"url\": \"https://yandex.ru\",\n  \"state\": \"active\"\n}\n",
+		},
+		{
+			name:   "Test case #8",
+			format: "table",
+			print:  func(p *printer) error { return p.stats(&client.Stats{URLs: 2, Users: 1}) },
+			want:   "URLS   2\nUSERS  1\n",
+		},
+		{
+			name:   "Test case #9",
+			format: "json",
+			print:  func(p *printer) error { return p.stats(&client.Stats{URLs: 2, Users: 1}) },
+			want:   "{\n  \"urls\": 2,\n  \"users\": 1\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p, err := newPrinter(&out, tt.format)
+			require.NoError(t, err)
+
+			require.NoError(t, tt.print(p))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/json"
@@ -22,17 +23,24 @@ import (
 	"time"
 
 	"github.com/Fe4p3b/url-shortener/internal/app/auth"
+	"github.com/Fe4p3b/url-shortener/internal/app/canonical"
+	"github.com/Fe4p3b/url-shortener/internal/app/policy"
 	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/geoip"
 	"github.com/Fe4p3b/url-shortener/internal/handlers"
 	grpcHandler "github.com/Fe4p3b/url-shortener/internal/handlers/grpc"
 	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
 	httpHandler "github.com/Fe4p3b/url-shortener/internal/handlers/http"
 	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
 	"github.com/Fe4p3b/url-shortener/internal/storage/file"
 	"github.com/Fe4p3b/url-shortener/internal/storage/pg"
 	env "github.com/caarlos0/env/v6"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 var (
@@ -50,8 +58,23 @@ type Config struct {
 	EnableHTTPS     bool   `env:"ENABLE_HTTPS,required" envDefault:"false" json:"enable_https"`
 	Certfile        string `env:"CERTFILE" envDefault:"cert" json:"certfile_path"`
 	CertKey         string `env:"PRIVATE_KEY" envDefault:"key" json:"certkey_path"`
+	GRPCAddress     string `env:"GRPC_ADDRESS" envDefault:":3200" json:"grpc_address"`
+	GRPCClientCA    string `env:"GRPC_CLIENT_CA" json:"grpc_client_ca"`
+	GRPCSinglePort  bool   `env:"GRPC_SINGLE_PORT" envDefault:"false" json:"grpc_single_port"`
+	GRPCWebOrigins  string `env:"GRPC_WEB_ORIGINS" json:"grpc_web_origins"`
 	ConfigFile      string `env:"CONFIG" envDefault:"config/config.json"`
 	TrustedNetworks string `env:"TRUSTED_SUBNET" envDefault:"192.168.1.1" json:"trusted_subnet"`
+	TrustedProxies  string `env:"TRUSTED_PROXIES" json:"trusted_proxies"`
+	InternalMethods string `env:"GRPC_INTERNAL_METHODS" envDefault:"/grpc.Shortener/GetStats /shortener.v2.Shortener/GetStats" json:"grpc_internal_methods"`
+	GeoIPDatabase   string `env:"GEOIP_DATABASE" json:"geoip_database"`
+	DomainAllowlist string `env:"DOMAIN_ALLOWLIST" json:"domain_allowlist"`
+	DomainDenylist  string `env:"DOMAIN_DENYLIST" json:"domain_denylist"`
+	DedupScope      string `env:"DEDUP_SCOPE" envDefault:"global" json:"dedup_scope"`
+	CanonicalSteps  string `env:"CANONICAL_STEPS" envDefault:"lowercase default_port sort_query tracking_params percent_encoding" json:"canonical_steps"`
+	CustomDomains   string `env:"CUSTOM_DOMAINS" json:"custom_domains"`
+	ShortenerHosts  string `env:"SHORTENER_HOSTS" envDefault:"bit.ly buff.ly cutt.ly goo.gl is.gd ow.ly rb.gy rebrand.ly shorturl.at t.co tiny.cc tinyurl.com" json:"shortener_hosts"`
+	ShortenerAction string `env:"SHORTENER_HOSTS_ACTION" envDefault:"flag" json:"shortener_hosts_action"`
+	TemplatesDir    string `env:"TEMPLATES_DIR" envDefault:"config/templates" json:"templates_dir"`
 }
 
 func main() {
@@ -78,8 +101,45 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if err = pg.SetDedupScope(models.DedupScope(cfg.DedupScope)); err != nil {
+		log.Fatal(err)
+	}
+
 	s := shortener.NewShortener(pg, cfg.BaseURL)
 
+	steps, err := canonical.ParseSteps(cfg.CanonicalSteps)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := canonical.New(steps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.SetCanonicalizer(c)
+
+	go func() {
+		if err := pg.BackfillCanonicalURLs(c.Canonicalize); err != nil {
+			log.Printf("backfill of canonical URLs is not finished, it continues on next start: %v", err)
+		}
+	}()
+
+	s.SetCustomDomains(strings.Fields(cfg.CustomDomains))
+	if err = s.SetShortenerHosts(strings.Fields(cfg.ShortenerHosts), shortener.ShortenerHostsAction(cfg.ShortenerAction)); err != nil {
+		log.Fatal(err)
+	}
+
+	var domainPolicy *policy.Policy
+	if cfg.DomainAllowlist != "" || cfg.DomainDenylist != "" {
+		domainPolicy, err = policy.New(cfg.DomainAllowlist, cfg.DomainDenylist)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.SetPolicy(domainPolicy)
+
+		applyPolicy(s)
+	}
+
 	auth, err := auth.NewAuth([]byte(cfg.Secret), pg)
 	if err != nil {
 		log.Fatal(err)
@@ -88,36 +148,133 @@ func main() {
 
 	handlers := handlers.NewHandler(s)
 
+	if cfg.GeoIPDatabase != "" {
+		geo, err := geoip.NewDB(cfg.GeoIPDatabase, geoip.DefaultReloadInterval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer geo.Close()
+
+		handlers.SetGeoIP(geo)
+	}
+
 	h := httpHandler.NewHandler(handlers)
+	if err = h.LoadTemplates(cfg.TemplatesDir); err != nil {
+		log.Fatal(err)
+	}
+	trustedPolicy, err := middleware.NewTrustedPolicy(strings.Fields(cfg.TrustedNetworks), strings.Fields(cfg.TrustedProxies))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if strings.TrimSpace(cfg.TrustedNetworks) != "" && strings.TrimSpace(cfg.TrustedProxies) == "" {
+		log.Println("warning: TRUSTED_PROXIES is empty, so X-Real-IP is ignored and trusted networks are checked by address of peer, set addresses of proxies, if server is behind a proxy")
+	}
+	h.SetTrustedPolicy(trustedPolicy)
 	h.Router.Use(middleware.GZIPReaderMiddleware, middleware.GZIPWriterMiddleware, authMiddleware.Middleware)
 	h.SetupAPIRouting()
 	h.SetupProfiling()
-	h.SetupInternalRouting(strings.Fields(cfg.TrustedNetworks))
+	h.SetupInternalRouting(trustedPolicy)
 
-	listen, err := net.Listen("tcp", ":3200")
-	if err != nil {
-		log.Fatal(err)
+	if cfg.EnableHTTPS {
+		if err := createCert(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	authInterceptor := grpcHandler.NewAuthInterceptor(auth)
+	trustedInterceptor := grpcHandler.NewTrustedInterceptor(trustedPolicy, strings.Fields(cfg.InternalMethods))
+	grpcOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(trustedInterceptor.Unary, authInterceptor.Unary),
+		grpc.ChainStreamInterceptor(trustedInterceptor.Stream, authInterceptor.Stream),
 	}
-	grpcServer := grpc.NewServer()
+	if !cfg.EnableHTTPS && cfg.GRPCClientCA != "" {
+		log.Fatal("client certificates of gRPC require HTTPS to be enabled")
+	}
+	if cfg.EnableHTTPS && !cfg.GRPCSinglePort {
+		creds, err := grpcHandler.ServerCredentials(cfg.Certfile, cfg.CertKey, cfg.GRPCClientCA)
+		if err != nil {
+			log.Fatal(err)
+		}
+		grpcOptions = append(grpcOptions, grpc.Creds(creds))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
 	shortenerServer := grpcHandler.NewShortenerServer(handlers)
+	shortenerServer.SetTrustedPolicy(trustedPolicy)
 	pb.RegisterShortenerServer(grpcServer, shortenerServer)
+	shortenerV2Server := grpcHandler.NewShortenerV2Server(handlers)
+	shortenerV2Server.SetTrustedPolicy(trustedPolicy)
+	pbv2.RegisterShortenerServer(grpcServer, shortenerV2Server)
+	healthChecker := grpcHandler.NewHealthChecker(handlers, grpcHandler.DefaultHealthInterval)
+	healthpb.RegisterHealthServer(grpcServer, healthChecker)
+	reflection.Register(grpcServer)
+
+	// gRPC-Web is served by HTTP server only for configured origins
+	requireClientCert := cfg.GRPCClientCA != ""
+	webOrigins := strings.Fields(cfg.GRPCWebOrigins)
+	grpcHTTPServer := grpcHandler.NewHTTPServer(grpcServer)
+	srv := &http.Server{Addr: cfg.Address, Handler: grpcHandler.NewWebHandler(grpcHTTPServer, h.Router, webOrigins, requireClientCert)}
+
+	// in single port mode gRPC and with origins gRPC-Web are served by
+	// HTTP server, client certificates are optional for HTTP and
+	// required for gRPC and gRPC-Web
+	if cfg.EnableHTTPS && (cfg.GRPCSinglePort || requireClientCert && len(webOrigins) > 0) {
+		srv.TLSConfig, err = grpcHandler.ServerTLSConfig(cfg.Certfile, cfg.CertKey, cfg.GRPCClientCA)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if requireClientCert {
+			srv.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
 
-	srv := &http.Server{Addr: cfg.Address, Handler: h.Router}
+	var listen net.Listener
+	if cfg.GRPCSinglePort {
+		srv.Handler = grpcHandler.NewMuxHandler(grpcHTTPServer, srv.Handler, requireClientCert)
+	} else {
+		listen, err = net.Listen("tcp", cfg.GRPCAddress)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	errgroup, ctx := errgroup.WithContext(context.Background())
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer stop()
 
+	if listen != nil {
+		errgroup.Go(func() error {
+			return grpcServer.Serve(listen)
+		})
+	}
+
 	errgroup.Go(func() error {
-		return grpcServer.Serve(listen)
+		healthChecker.Run(ctx)
+		return nil
 	})
 
-	errgroup.Go(func() error {
-		if cfg.EnableHTTPS {
-			if err := createCert(); err != nil {
-				return err
+	if domainPolicy != nil {
+		errgroup.Go(func() error {
+			hup := make(chan os.Signal, 1)
+			signal.Notify(hup, syscall.SIGHUP)
+			defer signal.Stop(hup)
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-hup:
+					if err := domainPolicy.Reload(); err != nil {
+						log.Printf("error reloading domain policy: %v", err)
+						continue
+					}
+					applyPolicy(s)
+				}
 			}
+		})
+	}
 
+	errgroup.Go(func() error {
+		if cfg.EnableHTTPS {
 			if err := srv.ListenAndServeTLS(cfg.Certfile, cfg.CertKey); err != http.ErrServerClosed {
 				return err
 			}
@@ -137,9 +294,16 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 		defer cancel()
 
-		grpcServer.GracefulStop()
+		healthChecker.Shutdown()
 
-		return srv.Shutdown(ctx)
+		// gRPC server can't drain gRPC-Web and single port requests of
+		// HTTP server, so HTTP server is shut down first, gRPC server
+		// waits for them and is stopped at once, if they aren't
+		// finished in time
+		err := srv.Shutdown(ctx)
+		grpcHTTPServer.Stop(ctx)
+
+		return err
 	})
 
 	if err := errgroup.Wait(); err != nil {
@@ -147,6 +311,17 @@ func main() {
 	}
 }
 
+// applyPolicy disables short URLs, that aren't allowed by domain policy.
+func applyPolicy(s shortener.ShortenerService) {
+	n, err := s.ApplyPolicy()
+	if err != nil {
+		log.Printf("error applying domain policy: %v", err)
+		return
+	}
+
+	log.Printf("domain policy is applied, %d short URLs are disabled", n)
+}
+
 func setConfig(cfg *Config) error {
 	err := env.Parse(cfg)
 	if err != nil {
@@ -162,6 +337,19 @@ func setConfig(cfg *Config) error {
 		enableHTTPS     bool
 		configFile      string
 		trustedNetworks string
+		trustedProxies  string
+		geoIPDatabase   string
+		domainAllowlist string
+		domainDenylist  string
+		dedupScope      string
+		canonicalSteps  string
+		customDomains   string
+		shortenerHosts  string
+		shortenerAction string
+		templatesDir    string
+		grpcAddress     string
+		grpcClientCA    string
+		grpcSinglePort  bool
 	)
 
 	flag.StringVar(&address, "a", "", "Адрес запуска HTTP-сервера")
@@ -172,6 +360,19 @@ func setConfig(cfg *Config) error {
 	flag.BoolVar(&enableHTTPS, "s", false, "Активация HTTPS")
 	flag.StringVar(&configFile, "c", "", "Конфигурационный файл")
 	flag.StringVar(&trustedNetworks, "t", "", "IP-адресса доверенных сетей")
+	flag.StringVar(&trustedProxies, "p", "", "IP-адреса доверенных прокси, передающих X-Real-IP")
+	flag.StringVar(&geoIPDatabase, "g", "", "Путь до файла базы данных GeoIP в формате MaxMind")
+	flag.StringVar(&domainAllowlist, "w", "", "Файл с разрешёнными доменами")
+	flag.StringVar(&domainDenylist, "x", "", "Файл с запрещёнными доменами")
+	flag.StringVar(&dedupScope, "u", "", "Область поиска дубликатов URL: global, user или none")
+	flag.StringVar(&canonicalSteps, "n", "", "Шаги канонизации URL перед поиском дубликатов")
+	flag.StringVar(&customDomains, "m", "", "Дополнительные домены сокращённых URL")
+	flag.StringVar(&shortenerHosts, "o", "", "Домены сторонних сервисов сокращения URL")
+	flag.StringVar(&shortenerAction, "r", "", "Действие с URL сторонних сервисов сокращения: reject или flag")
+	flag.StringVar(&templatesDir, "l", "", "Каталог с шаблонами HTML-страниц")
+	flag.StringVar(&grpcAddress, "e", "", "Адрес запуска gRPC-сервера")
+	flag.StringVar(&grpcClientCA, "i", "", "Файл с сертификатами CA для проверки клиентов gRPC")
+	flag.BoolVar(&grpcSinglePort, "j", false, "Обслуживание gRPC на порту HTTP-сервера")
 	flag.Parse()
 
 	if address != "" {
@@ -206,6 +407,58 @@ func setConfig(cfg *Config) error {
 		cfg.TrustedNetworks = trustedNetworks
 	}
 
+	if trustedProxies != "" {
+		cfg.TrustedProxies = trustedProxies
+	}
+
+	if geoIPDatabase != "" {
+		cfg.GeoIPDatabase = geoIPDatabase
+	}
+
+	if domainAllowlist != "" {
+		cfg.DomainAllowlist = domainAllowlist
+	}
+
+	if domainDenylist != "" {
+		cfg.DomainDenylist = domainDenylist
+	}
+
+	if dedupScope != "" {
+		cfg.DedupScope = dedupScope
+	}
+
+	if canonicalSteps != "" {
+		cfg.CanonicalSteps = canonicalSteps
+	}
+
+	if customDomains != "" {
+		cfg.CustomDomains = customDomains
+	}
+
+	if shortenerHosts != "" {
+		cfg.ShortenerHosts = shortenerHosts
+	}
+
+	if shortenerAction != "" {
+		cfg.ShortenerAction = shortenerAction
+	}
+
+	if templatesDir != "" {
+		cfg.TemplatesDir = templatesDir
+	}
+
+	if grpcAddress != "" {
+		cfg.GRPCAddress = grpcAddress
+	}
+
+	if grpcClientCA != "" {
+		cfg.GRPCClientCA = grpcClientCA
+	}
+
+	if grpcSinglePort {
+		cfg.GRPCSinglePort = grpcSinglePort
+	}
+
 	if err := readJSONConfig(cfg); err != nil {
 		return err
 	}
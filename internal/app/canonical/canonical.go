@@ -0,0 +1,268 @@
+// Package canonical provides canonicalization of URLs, that is
+// used to find duplicates of original URLs.
+package canonical
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Step is a step of canonicalization.
+type Step string
+
+const (
+	// StepLowercase lowercases scheme and host.
+	StepLowercase Step = "lowercase"
+
+	// StepDefaultPort drops port, that is default for scheme.
+	StepDefaultPort Step = "default_port"
+
+	// StepSortQuery sorts query parameters by name.
+	StepSortQuery Step = "sort_query"
+
+	// StepTrackingParams strips tracking query parameters.
+	StepTrackingParams Step = "tracking_params"
+
+	// StepPercentEncoding decodes percent-encoded unreserved characters
+	// and uppercases hex digits of the rest of percent-encodings.
+	StepPercentEncoding Step = "percent_encoding"
+)
+
+// DefaultSteps are all steps of canonicalization.
+var DefaultSteps = []Step{
+	StepLowercase,
+	StepDefaultPort,
+	StepSortQuery,
+	StepTrackingParams,
+	StepPercentEncoding,
+}
+
+var ErrorUnknownStep = errors.New("unknown canonicalization step")
+
+// defaultPorts maps schemes to their default ports.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// trackingParams are query parameters, that only identify source
+// of a visit and don't change destination.
+var trackingParams = map[string]struct{}{
+	"fbclid":    {},
+	"gclid":     {},
+	"dclid":     {},
+	"msclkid":   {},
+	"yclid":     {},
+	"ysclid":    {},
+	"igshid":    {},
+	"mc_cid":    {},
+	"mc_eid":    {},
+	"_ga":       {},
+	"_openstat": {},
+}
+
+// trackingPrefixes are prefixes of tracking query parameters.
+var trackingPrefixes = []string{"utm_"}
+
+// Canonicalizer builds canonical form of URLs by configured steps.
+type Canonicalizer struct {
+	steps map[Step]struct{}
+}
+
+// New returns Canonicalizer, that applies steps. Steps are always
+// applied in the order of DefaultSteps.
+func New(steps []Step) (*Canonicalizer, error) {
+	c := &Canonicalizer{steps: make(map[Step]struct{}, len(steps))}
+
+	for _, s := range steps {
+		if !isKnown(s) {
+			return nil, fmt.Errorf("%w: %q", ErrorUnknownStep, s)
+		}
+		c.steps[s] = struct{}{}
+	}
+
+	return c, nil
+}
+
+// ParseSteps parses space or comma separated names of steps.
+func ParseSteps(s string) ([]Step, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+
+	steps := make([]Step, 0, len(fields))
+	for _, f := range fields {
+		step := Step(f)
+		if !isKnown(step) {
+			return nil, fmt.Errorf("%w: %q", ErrorUnknownStep, f)
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// isKnown checks whether s is one of DefaultSteps.
+func isKnown(s Step) bool {
+	for _, step := range DefaultSteps {
+		if step == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// has checks whether step s is enabled.
+func (c *Canonicalizer) has(s Step) bool {
+	_, ok := c.steps[s]
+	return ok
+}
+
+// Canonicalize returns canonical form of URL u.
+func (c *Canonicalizer) Canonicalize(u string) (string, error) {
+	p, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	if c.has(StepLowercase) {
+		p.Scheme = strings.ToLower(p.Scheme)
+		p.Host = strings.ToLower(p.Host)
+	}
+
+	if c.has(StepDefaultPort) {
+		if port := p.Port(); port != "" && defaultPorts[strings.ToLower(p.Scheme)] == port {
+			p.Host = strings.TrimSuffix(p.Host, ":"+port)
+		}
+	}
+
+	if c.has(StepPercentEncoding) {
+		path := normalizeEscapes(p.EscapedPath())
+		p.Path, err = url.PathUnescape(path)
+		if err != nil {
+			return "", err
+		}
+		p.RawPath = path
+	}
+
+	if p.RawQuery != "" {
+		p.RawQuery = c.query(p.RawQuery)
+		p.ForceQuery = false
+	}
+
+	return p.String(), nil
+}
+
+// query applies steps to raw query. Encoding of parameters is kept,
+// so query isn't reencoded.
+func (c *Canonicalizer) query(raw string) string {
+	params := strings.Split(raw, "&")
+
+	kept := params[:0]
+	for _, p := range params {
+		if p == "" {
+			continue
+		}
+
+		if c.has(StepPercentEncoding) {
+			p = normalizeEscapes(p)
+		}
+
+		if c.has(StepTrackingParams) && isTrackingParam(p) {
+			continue
+		}
+
+		kept = append(kept, p)
+	}
+
+	if c.has(StepSortQuery) {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return paramName(kept[i]) < paramName(kept[j])
+		})
+	}
+
+	return strings.Join(kept, "&")
+}
+
+// paramName returns unescaped name of query parameter.
+func paramName(param string) string {
+	name := param
+	if i := strings.IndexByte(param, '='); i >= 0 {
+		name = param[:i]
+	}
+
+	if n, err := url.QueryUnescape(name); err == nil {
+		return n
+	}
+
+	return name
+}
+
+// isTrackingParam checks whether query parameter is a tracking one.
+func isTrackingParam(param string) bool {
+	name := strings.ToLower(paramName(param))
+
+	if _, ok := trackingParams[name]; ok {
+		return true
+	}
+
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeEscapes decodes percent-encoded unreserved characters
+// and uppercases hex digits of the rest of percent-encodings.
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+
+	return b.String()
+}
+
+// isUnreserved checks whether c is unreserved character of RFC 3986.
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+
+	return c - 'A' + 10
+}
@@ -0,0 +1,82 @@
+package canonical
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalizer_Canonicalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+		url   string
+		want  string
+	}{
+		{
+			name:  "Test case #1",
+			steps: DefaultSteps,
+			url:   "HTTP://Example.com:80/a?b=1&a=2",
+			want:  "http://example.com/a?a=2&b=1",
+		},
+		{
+			name:  "Test case #2",
+			steps: DefaultSteps,
+			url:   "http://example.com/a?a=2&b=1",
+			want:  "http://example.com/a?a=2&b=1",
+		},
+		{
+			name:  "Test case #3",
+			steps: DefaultSteps,
+			url:   "https://example.com:443/%7euser/%e2%82%ac?q=%7e%2f&utm_source=x&fbclid=y",
+			want:  "https://example.com/~user/%E2%82%AC?q=~%2F",
+		},
+		{
+			name:  "Test case #4",
+			steps: DefaultSteps,
+			url:   "https://example.com:8443/?utm_campaign=spring",
+			want:  "https://example.com:8443/",
+		},
+		{
+			name:  "Test case #5",
+			steps: []Step{StepLowercase},
+			url:   "HTTPS://EXAMPLE.com:443/Path?b=1&a=2&utm_source=x",
+			want:  "https://example.com:443/Path?b=1&a=2&utm_source=x",
+		},
+		{
+			name:  "Test case #6",
+			steps: []Step{StepSortQuery},
+			url:   "https://example.com/?b=2&a=1&b=1",
+			want:  "https://example.com/?a=1&b=2&b=1",
+		},
+		{
+			name:  "Test case #7",
+			steps: nil,
+			url:   "HTTPS://Example.com:443/%7e?b=1&a=2",
+			want:  "https://Example.com:443/%7e?b=1&a=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.steps)
+			assert.NoError(t, err)
+
+			got, err := c.Canonicalize(tt.url)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	got, err := ParseSteps("lowercase, sort_query default_port")
+	assert.NoError(t, err)
+	assert.Equal(t, []Step{StepLowercase, StepSortQuery, StepDefaultPort}, got)
+
+	got, err = ParseSteps("")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = ParseSteps("lowercase trailing_slash")
+	assert.ErrorIs(t, err, ErrorUnknownStep)
+}
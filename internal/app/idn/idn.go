@@ -0,0 +1,149 @@
+// Package idn provides conversion of internationalized domain names
+// and detection of domains, that imitate other domains by
+// similar-looking characters.
+package idn
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mtibben/confusables"
+	"golang.org/x/net/idna"
+)
+
+// Flags of domains, that look suspicious.
+const (
+	// FlagMixedScript is set, when a label of domain mixes letters
+	// of different scripts, like Latin and Cyrillic.
+	FlagMixedScript = "mixed_script"
+
+	// FlagConfusable is set, when a label of domain consists of
+	// characters, that are confusable with ASCII ones, so it looks
+	// like ASCII label.
+	FlagConfusable = "confusable"
+)
+
+// scripts are scripts, that letters of labels are checked for.
+var scripts = map[string]*unicode.RangeTable{
+	"Latin":    unicode.Latin,
+	"Cyrillic": unicode.Cyrillic,
+	"Greek":    unicode.Greek,
+	"Armenian": unicode.Armenian,
+	"Hebrew":   unicode.Hebrew,
+	"Arabic":   unicode.Arabic,
+	"Georgian": unicode.Georgian,
+	"Cherokee": unicode.Cherokee,
+	"Han":      unicode.Han,
+	"Hiragana": unicode.Hiragana,
+	"Katakana": unicode.Katakana,
+	"Hangul":   unicode.Hangul,
+	"Bopomofo": unicode.Bopomofo,
+	"Thai":     unicode.Thai,
+}
+
+// allowedMixes are combinations of scripts, that are commonly used
+// together, as in "highly restrictive" level of UTS #39.
+var allowedMixes = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// ToASCII converts host to its ASCII form, where internationalized
+// labels are encoded by punycode.
+func ToASCII(host string) (string, error) {
+	return idna.Lookup.ToASCII(host)
+}
+
+// Check returns flags of host, that can be either in ASCII or in
+// Unicode form. Host without flags doesn't look suspicious.
+func Check(host string) []string {
+	unicodeHost, err := idna.Lookup.ToUnicode(host)
+	if err != nil {
+		unicodeHost = host
+	}
+
+	set := make(map[string]struct{})
+	for _, label := range strings.Split(strings.ToLower(unicodeHost), ".") {
+		if IsASCII(label) {
+			continue
+		}
+
+		if isMixedScript(label) {
+			set[FlagMixedScript] = struct{}{}
+		}
+
+		if IsASCII(confusables.Skeleton(label)) {
+			set[FlagConfusable] = struct{}{}
+		}
+	}
+
+	flags := make([]string, 0, len(set))
+	for f := range set {
+		flags = append(flags, f)
+	}
+	sort.Strings(flags)
+
+	return flags
+}
+
+// isMixedScript checks whether letters of label belong to scripts,
+// that aren't used together.
+func isMixedScript(label string) bool {
+	used := make(map[string]struct{})
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		for name, table := range scripts {
+			if unicode.Is(table, r) {
+				used[name] = struct{}{}
+				break
+			}
+		}
+	}
+
+	if len(used) < 2 {
+		return false
+	}
+
+	for _, mix := range allowedMixes {
+		if contains(mix, used) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contains checks whether all scripts of used are in mix.
+func contains(mix []string, used map[string]struct{}) bool {
+	for name := range used {
+		found := false
+		for _, m := range mix {
+			if m == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsASCII checks whether s consists of ASCII characters only.
+func IsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,43 @@
+package idn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want []string
+	}{
+		{name: "Test case #1", host: "apple.com", want: []string{}},
+		{name: "Test case #2", host: "аpple.com", want: []string{FlagConfusable, FlagMixedScript}},
+		{name: "Test case #3", host: "xn--pple-43d.com", want: []string{FlagConfusable, FlagMixedScript}},
+		{name: "Test case #4", host: "аррӏе.com", want: []string{FlagConfusable}},
+		{name: "Test case #5", host: "пример.рф", want: []string{}},
+		{name: "Test case #6", host: "münchen.de", want: []string{}},
+		{name: "Test case #7", host: "東京tokyo.jp", want: []string{}},
+		{name: "Test case #8", host: "pаypal.com", want: []string{FlagConfusable, FlagMixedScript}},
+		{name: "Test case #9", host: "yandexя.ru", want: []string{FlagMixedScript}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Check(tt.host))
+		})
+	}
+}
+
+func TestToASCII(t *testing.T) {
+	got, err := ToASCII("аpple.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "xn--pple-43d.com", got)
+
+	got, err = ToASCII("Example.COM")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", got)
+
+	_, err = ToASCII("exa mple.com")
+	assert.Error(t, err)
+}
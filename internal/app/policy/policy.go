@@ -0,0 +1,192 @@
+// Package policy provides domain policy, that defines which
+// domains original URLs are allowed to lead to.
+package policy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/idn"
+)
+
+var (
+	ErrorDomainDenied     = errors.New("domain is denied")
+	ErrorDomainNotAllowed = errors.New("domain is not allowed")
+)
+
+// Policy checks domains by allow and deny rules, that are loaded from
+// files. Deny rules take precedence. If allow rules are loaded, only
+// matching domains are allowed. Each line of a file is a rule, empty
+// lines and lines starting with "#" are skipped. Rule without "*",
+// like "example.com", matches the domain and its subdomains. Rule
+// with "*", like "*.example.com" or "login-*.example.com", matches
+// the whole domain by wildcard, where "*" doesn't match ".". Rules
+// are converted to ASCII form, so hosts should be checked in ASCII
+// form too, where internationalized labels are encoded by punycode.
+type Policy struct {
+	mu sync.RWMutex
+
+	allowPath string
+	denyPath  string
+
+	allow []string
+	deny  []string
+}
+
+// New returns Policy, that loads rules from files. Empty path means,
+// that there are no rules of that kind.
+func New(allowPath string, denyPath string) (*Policy, error) {
+	p := &Policy{
+		allowPath: allowPath,
+		denyPath:  denyPath,
+	}
+
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Reload reads rules from files again, rules are replaced only if
+// both files are read successfully.
+func (p *Policy) Reload() error {
+	allow, err := readRules(p.allowPath)
+	if err != nil {
+		return err
+	}
+
+	deny, err := readRules(p.denyPath)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.allow = allow
+	p.deny = deny
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Check returns error, if host is denied or isn't allowed.
+func (p *Policy) Check(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if r, ok := match(p.deny, host); ok {
+		return fmt.Errorf("%w by rule %q", ErrorDomainDenied, r)
+	}
+
+	if len(p.allow) == 0 {
+		return nil
+	}
+
+	if _, ok := match(p.allow, host); !ok {
+		return ErrorDomainNotAllowed
+	}
+
+	return nil
+}
+
+// match returns the first rule, that matches host.
+func match(rules []string, host string) (string, bool) {
+	for _, r := range rules {
+		if strings.Contains(r, "*") {
+			if matchWildcard(r, host) {
+				return r, true
+			}
+			continue
+		}
+
+		if host == r || strings.HasSuffix(host, "."+r) {
+			return r, true
+		}
+	}
+
+	return "", false
+}
+
+// matchWildcard matches host by rule label by label, so "*" matches
+// a part of a single label.
+func matchWildcard(rule string, host string) bool {
+	ruleLabels := strings.Split(rule, ".")
+	hostLabels := strings.Split(host, ".")
+	if len(ruleLabels) != len(hostLabels) {
+		return false
+	}
+
+	for i, l := range ruleLabels {
+		if ok, _ := path.Match(l, hostLabels[i]); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readRules reads rules from file, if path is not empty.
+func readRules(filePath string) ([]string, error) {
+	if filePath == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []string
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		r := strings.TrimSpace(scanner.Text())
+		if r == "" || strings.HasPrefix(r, "#") {
+			continue
+		}
+
+		rule, err := asciiRule(strings.TrimSuffix(strings.ToLower(r), "."))
+		if _, matchErr := path.Match(rule, ""); err != nil || matchErr != nil || strings.ContainsAny(rule, "/ ") {
+			return nil, fmt.Errorf("%s:%d: invalid rule %q", filePath, n, r)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// asciiRule converts internationalized labels of rule to punycode,
+// labels with "*" should be in ASCII form already.
+func asciiRule(rule string) (string, error) {
+	labels := strings.Split(rule, ".")
+	for i, l := range labels {
+		if idn.IsASCII(l) {
+			continue
+		}
+
+		if strings.Contains(l, "*") {
+			return "", fmt.Errorf("wildcard label %q is not in ASCII form", l)
+		}
+
+		a, err := idn.ToASCII(l)
+		if err != nil {
+			return "", err
+		}
+		labels[i] = a
+	}
+
+	return strings.Join(labels, "."), nil
+}
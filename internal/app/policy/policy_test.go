@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRules(t *testing.T, name string, rules string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestPolicy_Check(t *testing.T) {
+	allow := writeRules(t, "allow", "# company domains\nexample.com\n*.example.org\n\n")
+	deny := writeRules(t, "deny", "evil.example.com\nlogin-*.example.org\nпример.рф\n")
+
+	p, err := New(allow, deny)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		host    string
+		wantErr error
+	}{
+		{name: "Test case #1", host: "example.com", wantErr: nil},
+		{name: "Test case #2", host: "WWW.Example.com.", wantErr: nil},
+		{name: "Test case #3", host: "docs.example.org", wantErr: nil},
+		{name: "Test case #4", host: "example.org", wantErr: ErrorDomainNotAllowed},
+		{name: "Test case #5", host: "a.b.example.org", wantErr: ErrorDomainNotAllowed},
+		{name: "Test case #6", host: "notexample.com", wantErr: ErrorDomainNotAllowed},
+		{name: "Test case #7", host: "evil.example.com", wantErr: ErrorDomainDenied},
+		{name: "Test case #8", host: "cdn.evil.example.com", wantErr: ErrorDomainDenied},
+		{name: "Test case #9", host: "login-bank.example.org", wantErr: ErrorDomainDenied},
+		{name: "Test case #10", host: "www.xn--e1afmkfd.xn--p1ai", wantErr: ErrorDomainDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.host)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicy_Reload(t *testing.T) {
+	deny := writeRules(t, "deny", "phishing.com\n")
+
+	p, err := New("", deny)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Check("yandex.ru"))
+	assert.ErrorIs(t, p.Check("phishing.com"), ErrorDomainDenied)
+
+	err = os.WriteFile(deny, []byte("phishing.com\nyandex.ru\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Reload())
+	assert.ErrorIs(t, p.Check("yandex.ru"), ErrorDomainDenied)
+
+	err = os.WriteFile(deny, []byte("bad/rule\n"), 0644)
+	assert.NoError(t, err)
+	assert.Error(t, p.Reload())
+	assert.ErrorIs(t, p.Check("yandex.ru"), ErrorDomainDenied)
+}
+
+func TestPolicy_Reload_invalidUnicodeWildcard(t *testing.T) {
+	_, err := New("", writeRules(t, "deny", "при*.рф\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+)
+
+// maxGeoRules is a maximum number of geo rules of short URL.
+const maxGeoRules = 50
+
+// matchGeo returns the first rule of rules, that contains country,
+// or nil.
+func matchGeo(rules []models.GeoRule, country string) *models.GeoRule {
+	if country == "" {
+		return nil
+	}
+
+	for i, r := range rules {
+		for _, c := range r.Countries {
+			if strings.EqualFold(c, country) {
+				return &rules[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateGeo checks geo rules of short URL.
+func validateGeo(rules []models.GeoRule) error {
+	if len(rules) > maxGeoRules {
+		return fmt.Errorf("%w: more than %d geo rules", ErrorInvalidOptions, maxGeoRules)
+	}
+
+	for i, r := range rules {
+		if len(r.Countries) == 0 {
+			return fmt.Errorf("%w: geo rule %d: no countries", ErrorInvalidOptions, i)
+		}
+
+		for _, c := range r.Countries {
+			if !isCountryCode(c) {
+				return fmt.Errorf("%w: geo rule %d: invalid country %q", ErrorInvalidOptions, i, c)
+			}
+		}
+
+		if _, err := url.Parse(r.URL); err != nil || r.URL == "" {
+			return fmt.Errorf("%w: geo rule %d: invalid url %q", ErrorInvalidOptions, i, r.URL)
+		}
+	}
+
+	return nil
+}
+
+// isCountryCode checks whether s looks like ISO 3166-1 alpha-2 code.
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package redirect
+
+import (
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestination_Geo(t *testing.T) {
+	u := &repositories.URL{
+		URL: "https://example.com",
+		Options: models.Options{
+			Targeting: []models.TargetingRule{
+				{Platform: models.PlatformIOS, URL: "https://apps.apple.com/app/id1"},
+			},
+			Geo: []models.GeoRule{
+				{Countries: []string{"KZ", "ru"}, URL: "https://example.kz"},
+				{Countries: []string{"GB"}, URL: "https://example.co.uk"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		visit *models.Visit
+		want  string
+	}{
+		{
+			name:  "Test case #1",
+			visit: &models.Visit{Country: "KZ"},
+			want:  "https://example.kz",
+		},
+		{
+			name:  "Test case #2",
+			visit: &models.Visit{Country: "RU"},
+			want:  "https://example.kz",
+		},
+		{
+			name:  "Test case #3",
+			visit: &models.Visit{Country: "GB", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 15_4 like Mac OS X)"},
+			want:  "https://apps.apple.com/app/id1",
+		},
+		{
+			name:  "Test case #4",
+			visit: &models.Visit{Country: "US"},
+			want:  "https://example.com",
+		},
+		{
+			name:  "Test case #5",
+			visit: &models.Visit{},
+			want:  "https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Destination(u, tt.visit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validateGeo(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []models.GeoRule
+		wantErr bool
+	}{
+		{
+			name:    "Test case #1",
+			rules:   []models.GeoRule{{Countries: []string{"KZ"}, URL: "https://example.kz"}},
+			wantErr: false,
+		},
+		{
+			name:    "Test case #2",
+			rules:   []models.GeoRule{{Countries: []string{}, URL: "https://example.kz"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #3",
+			rules:   []models.GeoRule{{Countries: []string{"KAZ"}, URL: "https://example.kz"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #4",
+			rules:   []models.GeoRule{{Countries: []string{"KZ"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGeo(tt.rules)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrorInvalidOptions)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
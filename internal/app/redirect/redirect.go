@@ -0,0 +1,223 @@
+// Package redirect provides business logic to build
+// destination of a short URL visit.
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+)
+
+const (
+	// maxTemplateLength is a maximum length of UTM template.
+	maxTemplateLength = 255
+
+	// maxTitleLength is a maximum length of title of short URL.
+	maxTitleLength = 255
+)
+
+var ErrorInvalidOptions = errors.New("invalid URL options")
+
+// UTMParameters are query parameters, that can be set by UTM templates.
+var UTMParameters = map[string]struct{}{
+	"utm_source":   {},
+	"utm_medium":   {},
+	"utm_campaign": {},
+	"utm_term":     {},
+	"utm_content":  {},
+}
+
+// placeholders are supported placeholders of UTM templates, they
+// are replaced with values of a visit.
+var placeholders = map[string]func(u *repositories.URL, v *models.Visit) string{
+	"code": func(u *repositories.URL, v *models.Visit) string {
+		return u.ShortURL
+	},
+	"date": func(u *repositories.URL, v *models.Visit) string {
+		return v.Time.UTC().Format("2006-01-02")
+	},
+	"referrer": func(u *repositories.URL, v *models.Visit) string {
+		r, err := url.Parse(v.Referer)
+		if err != nil {
+			return ""
+		}
+		return r.Hostname()
+	},
+}
+
+// Validate checks options of short URL before it is stored.
+func Validate(o *models.Options) error {
+	if utf8.RuneCountInString(o.Title) > maxTitleLength {
+		return fmt.Errorf("%w: title is longer than %d characters", ErrorInvalidOptions, maxTitleLength)
+	}
+
+	switch o.QueryPolicy {
+	case "", models.QueryDrop, models.QueryKeep, models.QueryOverride, models.QueryAppend:
+	default:
+		return fmt.Errorf("%w: unknown query policy %q", ErrorInvalidOptions, o.QueryPolicy)
+	}
+
+	for k, t := range o.UTM {
+		if _, ok := UTMParameters[k]; !ok {
+			return fmt.Errorf("%w: %q is not an UTM parameter", ErrorInvalidOptions, k)
+		}
+
+		if t == "" || len(t) > maxTemplateLength {
+			return fmt.Errorf("%w: template of %s should be from 1 to %d characters", ErrorInvalidOptions, k, maxTemplateLength)
+		}
+
+		_, err := expand(t, func(name string) (string, bool) {
+			_, ok := placeholders[name]
+			return "", ok
+		})
+		if err != nil {
+			return fmt.Errorf("%w: template of %s: %v", ErrorInvalidOptions, k, err)
+		}
+	}
+
+	if err := validateTargeting(o.Targeting); err != nil {
+		return err
+	}
+
+	if err := validateGeo(o.Geo); err != nil {
+		return err
+	}
+
+	return validateVariants(o.Variants)
+}
+
+// Destination returns URL, that visitor is redirected to. If visit is nil,
+// original URL is returned as is. Rules are applied in order: targeting
+// rules, geo rules, variants, and original URL is used, if none of them
+// is matched. Name of served variant is set to the visit.
+func Destination(u *repositories.URL, v *models.Visit) (string, error) {
+	if v == nil {
+		return u.URL, nil
+	}
+
+	target, err := resolveTarget(u, v)
+	if err != nil {
+		return "", err
+	}
+
+	policy := u.QueryPolicy
+	if policy == "" {
+		policy = models.QueryDrop
+	}
+
+	if (policy == models.QueryDrop || len(v.Query) == 0) && len(u.UTM) == 0 {
+		return target, nil
+	}
+
+	d, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	q := d.Query()
+	mergeQuery(q, v.Query, policy)
+
+	for k, t := range u.UTM {
+		value, err := expand(t, func(name string) (string, bool) {
+			f, ok := placeholders[name]
+			if !ok {
+				return "", false
+			}
+			return f(u, v), true
+		})
+		if err != nil {
+			return "", err
+		}
+		q.Set(k, value)
+	}
+
+	d.RawQuery = q.Encode()
+	return d.String(), nil
+}
+
+// resolveTarget returns URL of the first matched rule of short URL, or
+// original URL, and sets served variant to the visit.
+func resolveTarget(u *repositories.URL, v *models.Visit) (string, error) {
+	served := v.Variant
+	v.Variant = ""
+
+	if r := matchTargeting(u.Targeting, v); r != nil {
+		return r.URL, nil
+	}
+
+	if r := matchGeo(u.Geo, v.Country); r != nil {
+		return r.URL, nil
+	}
+
+	variant, err := chooseVariant(u.Variants, served)
+	if err != nil {
+		return "", err
+	}
+
+	if variant != nil {
+		v.Variant = variant.Name
+		return variant.URL, nil
+	}
+
+	return u.URL, nil
+}
+
+// mergeQuery merges query of a visit into query of original URL
+// by policy.
+func mergeQuery(dst url.Values, src url.Values, policy models.QueryPolicy) {
+	if policy == models.QueryDrop {
+		return
+	}
+
+	for k, values := range src {
+		if _, ok := dst[k]; ok {
+			switch policy {
+			case models.QueryKeep:
+				continue
+			case models.QueryOverride:
+				dst[k] = nil
+			}
+		}
+
+		dst[k] = append(dst[k], values...)
+	}
+}
+
+// expand replaces placeholders in curly braces of template t
+// with values returned by lookup.
+func expand(t string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+
+	for {
+		start := strings.IndexAny(t, "{}")
+		if start == -1 {
+			b.WriteString(t)
+			return b.String(), nil
+		}
+
+		if t[start] == '}' {
+			return "", errors.New("unexpected '}'")
+		}
+
+		end := strings.IndexAny(t[start+1:], "{}")
+		if end == -1 || t[start+1+end] == '{' {
+			return "", errors.New("unclosed '{'")
+		}
+		end += start + 1
+
+		name := t[start+1 : end]
+		value, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("unknown placeholder %q", name)
+		}
+
+		b.WriteString(t[:start])
+		b.WriteString(value)
+		t = t[end+1:]
+	}
+}
@@ -0,0 +1,174 @@
+package redirect
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options models.Options
+		wantErr bool
+	}{
+		{
+			name:    "Test case #1",
+			options: models.Options{},
+			wantErr: false,
+		},
+		{
+			name: "Test case #2",
+			options: models.Options{
+				QueryPolicy: models.QueryOverride,
+				UTM:         map[string]string{"utm_source": "short", "utm_campaign": "{code}-{date}"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Test case #3",
+			options: models.Options{QueryPolicy: "merge"},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #4",
+			options: models.Options{UTM: map[string]string{"source": "short"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #5",
+			options: models.Options{UTM: map[string]string{"utm_source": "{user}"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #6",
+			options: models.Options{UTM: map[string]string{"utm_source": "{code"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #7",
+			options: models.Options{UTM: map[string]string{"utm_source": ""}},
+			wantErr: true,
+		},
+		{
+			name: "Test case #8",
+			options: models.Options{Targeting: []models.TargetingRule{
+				{Platform: models.PlatformIOS, Language: "en-US", URL: "https://apps.apple.com"},
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "Test case #9",
+			options: models.Options{Targeting: []models.TargetingRule{{Platform: "symbian", URL: "https://nokia.com"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #10",
+			options: models.Options{Targeting: []models.TargetingRule{{Language: "en_US", URL: "https://yandex.ru"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #11",
+			options: models.Options{Title: strings.Repeat("я", 255)},
+			wantErr: false,
+		},
+		{
+			name:    "Test case #12",
+			options: models.Options{Title: strings.Repeat("a", 256)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.options)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrorInvalidOptions)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDestination(t *testing.T) {
+	visit := &models.Visit{
+		Query:   url.Values{"a": {"2"}, "utm_source": {"x"}},
+		Referer: "https://news.example.com/post/1",
+		Time:    time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name  string
+		url   repositories.URL
+		visit *models.Visit
+		want  string
+	}{
+		{
+			name:  "Test case #1",
+			url:   repositories.URL{URL: "http://yandex.ru/?a=1"},
+			visit: nil,
+			want:  "http://yandex.ru/?a=1",
+		},
+		{
+			name:  "Test case #2",
+			url:   repositories.URL{URL: "http://yandex.ru/?a=1"},
+			visit: visit,
+			want:  "http://yandex.ru/?a=1",
+		},
+		{
+			name: "Test case #3",
+			url: repositories.URL{
+				URL:     "http://yandex.ru/?a=1",
+				Options: models.Options{QueryPolicy: models.QueryKeep},
+			},
+			visit: visit,
+			want:  "http://yandex.ru/?a=1&utm_source=x",
+		},
+		{
+			name: "Test case #4",
+			url: repositories.URL{
+				URL:     "http://yandex.ru/?a=1",
+				Options: models.Options{QueryPolicy: models.QueryOverride},
+			},
+			visit: visit,
+			want:  "http://yandex.ru/?a=2&utm_source=x",
+		},
+		{
+			name: "Test case #5",
+			url: repositories.URL{
+				URL:     "http://yandex.ru/?a=1",
+				Options: models.Options{QueryPolicy: models.QueryAppend},
+			},
+			visit: visit,
+			want:  "http://yandex.ru/?a=1&a=2&utm_source=x",
+		},
+		{
+			name: "Test case #6",
+			url: repositories.URL{
+				URL:      "http://yandex.ru/",
+				ShortURL: "asdf",
+				Options: models.Options{
+					QueryPolicy: models.QueryOverride,
+					UTM: map[string]string{
+						"utm_source":   "{referrer}",
+						"utm_campaign": "spring sale&{code}/{date}",
+					},
+				},
+			},
+			visit: visit,
+			want:  "http://yandex.ru/?a=2&utm_campaign=spring+sale%26asdf%2F2022-04-01&utm_source=news.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Destination(&tt.url, tt.visit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
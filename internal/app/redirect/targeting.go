@@ -0,0 +1,186 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+)
+
+// maxTargetingRules is a maximum number of targeting rules of short URL.
+const maxTargetingRules = 20
+
+// platforms are known platforms, that rules can target.
+var platforms = map[string]struct{}{
+	models.PlatformIOS:     {},
+	models.PlatformAndroid: {},
+	models.PlatformWindows: {},
+	models.PlatformMacOS:   {},
+	models.PlatformLinux:   {},
+	models.PlatformOther:   {},
+}
+
+// Platform detects platform of visitor by User-Agent header.
+func Platform(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		return models.PlatformIOS
+	case strings.Contains(userAgent, "Android"):
+		return models.PlatformAndroid
+	case strings.Contains(userAgent, "Windows"):
+		return models.PlatformWindows
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		return models.PlatformMacOS
+	case strings.Contains(userAgent, "Linux"), strings.Contains(userAgent, "X11"):
+		return models.PlatformLinux
+	}
+
+	return models.PlatformOther
+}
+
+// Languages parses Accept-Language header and returns lowercased
+// language tags, that visitor accepts, ordered by preference.
+func Languages(acceptLanguage string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := parseQuality(params); ok {
+			q = v
+		}
+		if q <= 0 {
+			continue
+		}
+
+		languages = append(languages, language{tag: tag, q: q})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+
+	tags := make([]string, 0, len(languages))
+	for _, l := range languages {
+		tags = append(tags, l.tag)
+	}
+
+	return tags
+}
+
+// parseQuality parses "q=" parameter of Accept-Language part.
+func parseQuality(params string) (float64, bool) {
+	for _, p := range strings.Split(params, ";") {
+		k, v, ok := cut(strings.TrimSpace(p), "=")
+		if !ok || strings.TrimSpace(k) != "q" {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		return q, true
+	}
+
+	return 0, false
+}
+
+// matchTargeting returns the first rule of rules, that matches visit,
+// or nil.
+func matchTargeting(rules []models.TargetingRule, v *models.Visit) *models.TargetingRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	platform := Platform(v.UserAgent)
+	languages := Languages(v.AcceptLanguage)
+
+	for i, r := range rules {
+		if r.Platform != "" && r.Platform != platform {
+			continue
+		}
+
+		if r.Language != "" && !acceptsLanguage(languages, r.Language) {
+			continue
+		}
+
+		return &rules[i]
+	}
+
+	return nil
+}
+
+// acceptsLanguage checks whether language tag is accepted. Tag without
+// region, like "en", matches any region of the language.
+func acceptsLanguage(languages []string, tag string) bool {
+	tag = strings.ToLower(tag)
+	for _, l := range languages {
+		if l == tag || strings.HasPrefix(l, tag+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateTargeting checks targeting rules of short URL.
+func validateTargeting(rules []models.TargetingRule) error {
+	if len(rules) > maxTargetingRules {
+		return fmt.Errorf("%w: more than %d targeting rules", ErrorInvalidOptions, maxTargetingRules)
+	}
+
+	for i, r := range rules {
+		if _, ok := platforms[r.Platform]; r.Platform != "" && !ok {
+			return fmt.Errorf("%w: targeting rule %d: unknown platform %q", ErrorInvalidOptions, i, r.Platform)
+		}
+
+		if r.Language != "" && !isLanguageTag(r.Language) {
+			return fmt.Errorf("%w: targeting rule %d: invalid language %q", ErrorInvalidOptions, i, r.Language)
+		}
+
+		if _, err := url.Parse(r.URL); err != nil || r.URL == "" {
+			return fmt.Errorf("%w: targeting rule %d: invalid url %q", ErrorInvalidOptions, i, r.URL)
+		}
+	}
+
+	return nil
+}
+
+// isLanguageTag checks whether s looks like language tag, like "en"
+// or "zh-Hant-TW".
+func isLanguageTag(s string) bool {
+	for _, subtag := range strings.Split(s, "-") {
+		if len(subtag) == 0 || len(subtag) > 8 {
+			return false
+		}
+
+		for _, c := range subtag {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// cut slices s around the first instance of sep.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,129 @@
+package redirect
+
+import (
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{
+			name:      "Test case #1",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 15_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.4 Mobile/15E148 Safari/604.1",
+			want:      models.PlatformIOS,
+		},
+		{
+			name:      "Test case #2",
+			userAgent: "Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Mobile Safari/537.36",
+			want:      models.PlatformAndroid,
+		},
+		{
+			name:      "Test case #3",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Safari/537.36",
+			want:      models.PlatformWindows,
+		},
+		{
+			name:      "Test case #4",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.4 Safari/605.1.15",
+			want:      models.PlatformMacOS,
+		},
+		{
+			name:      "Test case #5",
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:99.0) Gecko/20100101 Firefox/99.0",
+			want:      models.PlatformLinux,
+		},
+		{
+			name:      "Test case #6",
+			userAgent: "curl/7.79.1",
+			want:      models.PlatformOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Platform(tt.userAgent))
+		})
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           []string
+	}{
+		{
+			name:           "Test case #1",
+			acceptLanguage: "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7",
+			want:           []string{"ru-ru", "ru", "en-us", "en"},
+		},
+		{
+			name:           "Test case #2",
+			acceptLanguage: "en;q=0.5, kk, de;q=0, *;q=0.1",
+			want:           []string{"kk", "en"},
+		},
+		{
+			name:           "Test case #3",
+			acceptLanguage: "",
+			want:           []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Languages(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestDestination_Targeting(t *testing.T) {
+	u := &repositories.URL{
+		URL: "https://example.com/app",
+		Options: models.Options{
+			Targeting: []models.TargetingRule{
+				{Platform: models.PlatformIOS, URL: "https://apps.apple.com/app/id1"},
+				{Platform: models.PlatformAndroid, URL: "https://play.google.com/store/apps/details?id=app"},
+				{Language: "ru", URL: "https://example.com/ru/app"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		visit *models.Visit
+		want  string
+	}{
+		{
+			name:  "Test case #1",
+			visit: &models.Visit{UserAgent: "Mozilla/5.0 (iPad; CPU OS 15_4 like Mac OS X)", AcceptLanguage: "ru"},
+			want:  "https://apps.apple.com/app/id1",
+		},
+		{
+			name:  "Test case #2",
+			visit: &models.Visit{UserAgent: "Mozilla/5.0 (Linux; Android 12)"},
+			want:  "https://play.google.com/store/apps/details?id=app",
+		},
+		{
+			name:  "Test case #3",
+			visit: &models.Visit{UserAgent: "Mozilla/5.0 (Windows NT 10.0)", AcceptLanguage: "en;q=0.9,ru-KZ;q=0.5"},
+			want:  "https://example.com/ru/app",
+		},
+		{
+			name:  "Test case #4",
+			visit: &models.Visit{UserAgent: "Mozilla/5.0 (Windows NT 10.0)", AcceptLanguage: "en"},
+			want:  "https://example.com/app",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Destination(u, tt.visit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package redirect
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/url"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+)
+
+const (
+	// maxVariants is a maximum number of variants of short URL.
+	maxVariants = 10
+
+	// maxVariantNameLength is a maximum length of variant name.
+	maxVariantNameLength = 32
+)
+
+// chooseVariant returns variant for a visit. If visitor was served
+// variant before, and it still exists with positive weight, it is
+// returned again, otherwise variant is chosen randomly by weight.
+// If there are no variants nil is returned.
+func chooseVariant(variants []models.Variant, served string) (*models.Variant, error) {
+	var total uint
+	for i, v := range variants {
+		if v.Name == served && v.Weight > 0 {
+			return &variants[i], nil
+		}
+		total += v.Weight
+	}
+
+	if total == 0 {
+		return nil, nil
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(total)))
+	if err != nil {
+		return nil, err
+	}
+
+	r := uint(n.Uint64())
+	for i, v := range variants {
+		if r < v.Weight {
+			return &variants[i], nil
+		}
+		r -= v.Weight
+	}
+
+	return nil, nil
+}
+
+// validateVariants checks variants of short URL.
+func validateVariants(variants []models.Variant) error {
+	if len(variants) > maxVariants {
+		return fmt.Errorf("%w: more than %d variants", ErrorInvalidOptions, maxVariants)
+	}
+
+	names := make(map[string]struct{})
+	var total uint
+	for i, v := range variants {
+		if !isVariantName(v.Name) {
+			return fmt.Errorf("%w: variant %d: name should be from 1 to %d letters, digits, '-' or '_'", ErrorInvalidOptions, i, maxVariantNameLength)
+		}
+
+		if _, ok := names[v.Name]; ok {
+			return fmt.Errorf("%w: variant %d: duplicate name %q", ErrorInvalidOptions, i, v.Name)
+		}
+		names[v.Name] = struct{}{}
+
+		if _, err := url.Parse(v.URL); err != nil || v.URL == "" {
+			return fmt.Errorf("%w: variant %d: invalid url %q", ErrorInvalidOptions, i, v.URL)
+		}
+
+		if v.Weight > 1000000 {
+			return fmt.Errorf("%w: variant %d: weight is more than 1000000", ErrorInvalidOptions, i)
+		}
+		total += v.Weight
+	}
+
+	if len(variants) > 0 && total == 0 {
+		return fmt.Errorf("%w: total weight of variants is 0", ErrorInvalidOptions)
+	}
+
+	return nil
+}
+
+// isVariantName checks whether s can be used as variant name.
+func isVariantName(s string) bool {
+	if len(s) == 0 || len(s) > maxVariantNameLength {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,161 @@
+package redirect
+
+import (
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_chooseVariant(t *testing.T) {
+	variants := []models.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 0},
+		{Name: "b", URL: "https://example.com/b", Weight: 1},
+		{Name: "c", URL: "https://example.com/c", Weight: 3},
+	}
+
+	tests := []struct {
+		name     string
+		variants []models.Variant
+		served   string
+		want     []string
+	}{
+		{
+			name:     "Test case #1",
+			variants: variants,
+			served:   "",
+			want:     []string{"b", "c"},
+		},
+		{
+			name:     "Test case #2",
+			variants: variants,
+			served:   "b",
+			want:     []string{"b"},
+		},
+		{
+			name:     "Test case #3",
+			variants: variants,
+			served:   "a",
+			want:     []string{"b", "c"},
+		},
+		{
+			name:     "Test case #4",
+			variants: variants,
+			served:   "removed",
+			want:     []string{"b", "c"},
+		},
+		{
+			name:     "Test case #5",
+			variants: nil,
+			served:   "b",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got, err := chooseVariant(tt.variants, tt.served)
+				assert.NoError(t, err)
+
+				if tt.want == nil {
+					assert.Nil(t, got)
+					continue
+				}
+				assert.Contains(t, tt.want, got.Name)
+			}
+		})
+	}
+}
+
+func TestDestination_Variants(t *testing.T) {
+	u := &repositories.URL{
+		URL: "https://example.com",
+		Options: models.Options{
+			Geo: []models.GeoRule{{Countries: []string{"KZ"}, URL: "https://example.kz"}},
+			Variants: []models.Variant{
+				{Name: "a", URL: "https://example.com/a", Weight: 1},
+				{Name: "b", URL: "https://example.com/b", Weight: 0},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		visit       *models.Visit
+		want        string
+		wantVariant string
+	}{
+		{
+			name:        "Test case #1",
+			visit:       &models.Visit{},
+			want:        "https://example.com/a",
+			wantVariant: "a",
+		},
+		{
+			name:        "Test case #2",
+			visit:       &models.Visit{Variant: "b"},
+			want:        "https://example.com/a",
+			wantVariant: "a",
+		},
+		{
+			name:        "Test case #3",
+			visit:       &models.Visit{Country: "KZ", Variant: "a"},
+			want:        "https://example.kz",
+			wantVariant: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Destination(u, tt.visit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantVariant, tt.visit.Variant)
+		})
+	}
+}
+
+func Test_validateVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []models.Variant
+		wantErr  bool
+	}{
+		{
+			name: "Test case #1",
+			variants: []models.Variant{
+				{Name: "control", URL: "https://example.com/a", Weight: 50},
+				{Name: "new-landing", URL: "https://example.com/b", Weight: 50},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Test case #2",
+			variants: []models.Variant{
+				{Name: "a", URL: "https://example.com/a", Weight: 1},
+				{Name: "a", URL: "https://example.com/b", Weight: 1},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Test case #3",
+			variants: []models.Variant{{Name: "a", URL: "https://example.com/a", Weight: 0}},
+			wantErr:  true,
+		},
+		{
+			name:     "Test case #4",
+			variants: []models.Variant{{Name: "a b", URL: "https://example.com/a", Weight: 1}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVariants(tt.variants)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrorInvalidOptions)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package shortener
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/idn"
+)
+
+// ShortenerHostsAction defines what is done with URLs, that lead to
+// known third-party shorteners.
+type ShortenerHostsAction string
+
+const (
+	// ShortenerHostsReject rejects URLs of third-party shorteners.
+	ShortenerHostsReject ShortenerHostsAction = "reject"
+
+	// ShortenerHostsFlag accepts URLs of third-party shorteners, but
+	// flags them, so visitors are warned before redirect.
+	ShortenerHostsFlag ShortenerHostsAction = "flag"
+)
+
+// FlagShortener is set, when destination is a third-party shortener,
+// that hides the real destination.
+const FlagShortener = "shortener"
+
+var ErrorUnknownShortenerHostsAction = errors.New("unknown shortener hosts action")
+
+// DefaultShortenerHosts are hosts of well-known third-party shorteners.
+var DefaultShortenerHosts = []string{
+	"bit.ly",
+	"buff.ly",
+	"cutt.ly",
+	"goo.gl",
+	"is.gd",
+	"ow.ly",
+	"rb.gy",
+	"rebrand.ly",
+	"shorturl.at",
+	"t.co",
+	"tiny.cc",
+	"tinyurl.com",
+}
+
+// SetCustomDomains sets domains, that short URLs are served on besides
+// host of base URL, URLs, that lead to them, are rejected.
+func (s *shortener) SetCustomDomains(domains []string) {
+	s.ownHosts = normalizeHosts(append([]string{hostname(s.BaseURL)}, domains...))
+}
+
+// SetShortenerHosts sets hosts of third-party shorteners and action,
+// that is done with URLs, that lead to them or to their subdomains.
+func (s *shortener) SetShortenerHosts(hosts []string, action ShortenerHostsAction) error {
+	switch action {
+	case ShortenerHostsReject, ShortenerHostsFlag:
+	default:
+		return fmt.Errorf("%w: %q", ErrorUnknownShortenerHostsAction, action)
+	}
+
+	s.shortenerHosts = normalizeHosts(hosts)
+	s.shortenerAction = action
+	return nil
+}
+
+// checkHost returns why host of valid URL u can't be a destination
+// of redirect, or empty string, if it can.
+func (s *shortener) checkHost(u string) string {
+	host := normalizeHost(hostname(u))
+
+	for _, h := range s.ownHosts {
+		if host == h {
+			return "leads to this shortener, use original URL instead"
+		}
+	}
+
+	if s.shortenerAction == ShortenerHostsReject && s.isShortenerHost(host) {
+		return fmt.Sprintf("leads to shortener %q, use original URL instead", host)
+	}
+
+	return ""
+}
+
+// isShortenerHost checks whether host is a third-party shortener or
+// its subdomain.
+func (s *shortener) isShortenerHost(host string) bool {
+	for _, h := range s.shortenerHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeHosts returns normalized non-empty hosts.
+func normalizeHosts(hosts []string) []string {
+	var result []string
+	for _, h := range hosts {
+		if h = normalizeHost(h); h != "" {
+			result = append(result, h)
+		}
+	}
+
+	return result
+}
+
+// normalizeHost returns lowercase ASCII form of host without trailing
+// dot, so hosts can be compared.
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if idn.IsASCII(host) {
+		return host
+	}
+
+	if h, err := idn.ToASCII(host); err == nil {
+		return h
+	}
+
+	return host
+}
@@ -0,0 +1,190 @@
+package shortener
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
+)
+
+const (
+	// MaxReasonLength is a maximum length of reason of report,
+	// disabling or flagging.
+	MaxReasonLength = 500
+
+	// FlagModerator is set, when moderator flags short URL, so
+	// visitors are warned before redirect.
+	FlagModerator = "moderator"
+)
+
+var (
+	ErrorInvalidReason = fmt.Errorf("reason should be from 1 to %d characters", MaxReasonLength)
+	ErrorURLIsDeleted  = errors.New("URL is deleted")
+)
+
+// Report implements ShortenerService Report method.
+func (s *shortener) Report(r *models.Report) error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if err := checkReason(r.Reason); err != nil {
+		return err
+	}
+
+	if err := s.findExisting(r.ShortURL); err != nil {
+		return err
+	}
+
+	r.Status = models.ReportOpen
+	if err := s.r.AddReport(r); err != nil {
+		return err
+	}
+
+	actor := r.Reporter
+	if actor == "" {
+		actor = r.IP
+	}
+
+	return s.r.AddAuditEntry(&models.AuditEntry{
+		Action:   models.AuditReport,
+		ShortURL: r.ShortURL,
+		Actor:    actor,
+		Reason:   r.Reason,
+	})
+}
+
+// GetReports implements ShortenerService GetReports method.
+func (s *shortener) GetReports(status models.ReportStatus) ([]models.Report, error) {
+	return s.r.GetReports(status)
+}
+
+// Disable implements ShortenerService Disable method.
+// Open reports of short URL are resolved.
+func (s *shortener) Disable(shortURL string, reason string, actor string) error {
+	reason = strings.TrimSpace(reason)
+	if err := checkReason(reason); err != nil {
+		return err
+	}
+
+	if err := s.findExisting(shortURL); err != nil {
+		return err
+	}
+
+	if err := s.r.DisableURLs(reason, []string{shortURL}); err != nil {
+		return err
+	}
+
+	if err := s.r.ResolveReports(shortURL); err != nil {
+		return err
+	}
+
+	return s.r.AddAuditEntry(&models.AuditEntry{
+		Action:   models.AuditDisable,
+		ShortURL: shortURL,
+		Actor:    actor,
+		Reason:   reason,
+	})
+}
+
+// Restore implements ShortenerService Restore method.
+// Open reports of short URL are resolved.
+func (s *shortener) Restore(shortURL string, actor string) error {
+	if err := s.findExisting(shortURL); err != nil {
+		return err
+	}
+
+	if err := s.r.EnableURLs([]string{shortURL}); err != nil {
+		return err
+	}
+
+	if err := s.r.ResolveReports(shortURL); err != nil {
+		return err
+	}
+
+	return s.r.AddAuditEntry(&models.AuditEntry{
+		Action:   models.AuditRestore,
+		ShortURL: shortURL,
+		Actor:    actor,
+	})
+}
+
+// Flag implements ShortenerService Flag method.
+func (s *shortener) Flag(shortURL string, reason string, actor string) error {
+	reason = strings.TrimSpace(reason)
+	if err := checkReason(reason); err != nil {
+		return err
+	}
+
+	if err := s.r.AddFlag(shortURL, FlagModerator); err != nil {
+		return err
+	}
+
+	if err := s.r.ResolveReports(shortURL); err != nil {
+		return err
+	}
+
+	return s.r.AddAuditEntry(&models.AuditEntry{
+		Action:   models.AuditFlag,
+		ShortURL: shortURL,
+		Actor:    actor,
+		Reason:   reason,
+	})
+}
+
+// Unflag implements ShortenerService Unflag method.
+// Flags, that are set by validation of destinations, are kept.
+func (s *shortener) Unflag(shortURL string, actor string) error {
+	if err := s.r.RemoveFlag(shortURL, FlagModerator); err != nil {
+		return err
+	}
+
+	return s.r.AddAuditEntry(&models.AuditEntry{
+		Action:   models.AuditUnflag,
+		ShortURL: shortURL,
+		Actor:    actor,
+	})
+}
+
+// GetAuditLog implements ShortenerService GetAuditLog method.
+func (s *shortener) GetAuditLog(shortURL string) ([]models.AuditEntry, error) {
+	return s.r.GetAuditLog(shortURL)
+}
+
+// findExisting checks whether short URL exists and is not deleted.
+func (s *shortener) findExisting(shortURL string) error {
+	u, err := s.r.Find(shortURL)
+	if err != nil {
+		return err
+	}
+
+	if u == nil {
+		return storage.ErrorNoLinkFound
+	}
+
+	if u.IsDeleted {
+		return ErrorURLIsDeleted
+	}
+
+	return nil
+}
+
+// hasFlag checks whether flags contain flag.
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkReason checks length of reason.
+func checkReason(reason string) error {
+	if reason == "" || utf8.RuneCountInString(reason) > MaxReasonLength {
+		return ErrorInvalidReason
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package shortener
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
+	"github.com/Fe4p3b/url-shortener/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_shortener_Report(t *testing.T) {
+	m := memory.NewMemory(map[string]string{"asdf": "https://yandex.ru"})
+	s := NewShortener(m, "http://localhost:8080")
+
+	tests := []struct {
+		name   string
+		report models.Report
+		err    error
+	}{
+		{name: "Test case #1", report: models.Report{ShortURL: "asdf", Reason: " phishing ", IP: "127.0.0.1"}},
+		{name: "Test case #2", report: models.Report{ShortURL: "asdf", Reason: "  "}, err: ErrorInvalidReason},
+		{name: "Test case #3", report: models.Report{ShortURL: "asdf", Reason: strings.Repeat("a", MaxReasonLength+1)}, err: ErrorInvalidReason},
+		{name: "Test case #4", report: models.Report{ShortURL: "qwerty", Reason: "spam"}, err: storage.ErrorNoLinkFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Report(&tt.report)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.ReportOpen, tt.report.Status)
+			assert.NotZero(t, tt.report.ID)
+		})
+	}
+
+	reports, err := s.GetReports(models.ReportOpen)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "phishing", reports[0].Reason)
+}
+
+func Test_shortener_DisableRestore(t *testing.T) {
+	m := memory.NewMemory(map[string]string{"asdf": "https://yandex.ru", "qwerty": "https://google.com"})
+	s := NewShortener(m, "http://localhost:8080")
+
+	assert.NoError(t, s.Report(&models.Report{ShortURL: "asdf", Reason: "phishing", Reporter: "user"}))
+	assert.NoError(t, s.Report(&models.Report{ShortURL: "qwerty", Reason: "spam", Reporter: "user"}))
+
+	assert.ErrorIs(t, s.Disable("asdf", "", "10.0.0.1"), ErrorInvalidReason)
+	assert.NoError(t, s.Disable("asdf", "phishing site", "10.0.0.1"))
+
+	e, err := s.Expand("asdf")
+	assert.NoError(t, err)
+	assert.Equal(t, models.LinkDisabled, e.State)
+	assert.Equal(t, "phishing site", e.Reason)
+
+	reports, err := s.GetReports(models.ReportOpen)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "qwerty", reports[0].ShortURL)
+
+	assert.NoError(t, s.Restore("asdf", "10.0.0.2"))
+
+	e, err = s.Expand("asdf")
+	assert.NoError(t, err)
+	assert.Equal(t, models.LinkActive, e.State)
+
+	entries, err := s.GetAuditLog("asdf")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, models.AuditReport, entries[0].Action)
+	assert.Equal(t, "user", entries[0].Actor)
+	assert.Equal(t, models.AuditDisable, entries[1].Action)
+	assert.Equal(t, "phishing site", entries[1].Reason)
+	assert.Equal(t, models.AuditRestore, entries[2].Action)
+	assert.Equal(t, "10.0.0.2", entries[2].Actor)
+}
+
+func Test_shortener_Flag_ConcurrentUpdate(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	s := NewShortener(m, "http://localhost:8080")
+	rules := []models.TargetingRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com"}}
+
+	for i := 0; i < 50; i++ {
+		u := &models.URL{URL: fmt.Sprintf("https://yandex.ru/%d", i), UserID: "user"}
+		_, err := s.Store(u)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := s.UpdateOptions("user", u.ShortURL, func(o *models.Options) { o.Targeting = rules })
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Flag(u.ShortURL, "scam", "10.0.0.1"))
+		}()
+		wg.Wait()
+
+		o, err := s.GetOptions("user", u.ShortURL)
+		require.NoError(t, err)
+		assert.Equal(t, rules, o.Targeting)
+		assert.Equal(t, []string{FlagModerator}, o.Flags)
+	}
+}
+
+// interleavingRepository runs before, when flags are changed, but
+// before they are written, like a concurrent request would do.
+type interleavingRepository struct {
+	repositories.ShortenerRepository
+	before func()
+}
+
+func (r *interleavingRepository) AddFlag(shortURL string, flag string) error {
+	r.before()
+	return r.ShortenerRepository.AddFlag(shortURL, flag)
+}
+
+func (r *interleavingRepository) RemoveFlag(shortURL string, flag string) error {
+	r.before()
+	return r.ShortenerRepository.RemoveFlag(shortURL, flag)
+}
+
+func Test_shortener_Flag_InterleavedUpdate(t *testing.T) {
+	r := &interleavingRepository{ShortenerRepository: memory.NewMemory(map[string]string{})}
+	s := NewShortener(r, "http://localhost:8080")
+	assert.NoError(t, s.SetShortenerHosts(DefaultShortenerHosts, ShortenerHostsFlag))
+
+	u := &models.URL{URL: "https://yandex.ru", UserID: "user"}
+	_, err := s.Store(u)
+	require.NoError(t, err)
+
+	variants := []models.Variant{{Name: "a", URL: "https://bit.ly/asdf", Weight: 1}}
+	r.before = func() {
+		_, err := s.UpdateOptions("user", u.ShortURL, func(o *models.Options) { o.Variants = variants })
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, s.Flag(u.ShortURL, "scam", "10.0.0.1"))
+
+	o, err := s.GetOptions("user", u.ShortURL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{FlagShortener, FlagModerator}, o.Flags)
+
+	r.before = func() {
+		_, err := s.UpdateOptions("user", u.ShortURL, func(o *models.Options) { o.Variants = nil })
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, s.Unflag(u.ShortURL, "10.0.0.1"))
+
+	o, err = s.GetOptions("user", u.ShortURL)
+	require.NoError(t, err)
+	assert.Empty(t, o.Flags)
+}
+
+func Test_shortener_Flag(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	s := NewShortener(m, "http://localhost:8080")
+
+	u := &models.URL{URL: "https://bit.ly/asdf", UserID: "user"}
+	assert.NoError(t, s.SetShortenerHosts(DefaultShortenerHosts, ShortenerHostsFlag))
+	_, err := s.Store(u)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, s.Flag(u.ShortURL, "", "10.0.0.1"), ErrorInvalidReason)
+	assert.NoError(t, s.Flag(u.ShortURL, "scam", "10.0.0.1"))
+
+	o, err := s.GetOptions("user", u.ShortURL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{FlagShortener, FlagModerator}, o.Flags)
+
+	o.Flags = nil
+	o.Title = "Link"
+	assert.NoError(t, s.SetOptions("user", u.ShortURL, o))
+
+	e, err := s.Expand(u.ShortURL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{FlagShortener, FlagModerator}, e.Flags)
+
+	assert.NoError(t, s.Unflag(u.ShortURL, "10.0.0.1"))
+
+	e, err = s.Expand(u.ShortURL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{FlagShortener}, e.Flags)
+
+	entries, err := s.GetAuditLog(u.ShortURL)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, models.AuditFlag, entries[0].Action)
+	assert.Equal(t, models.AuditUnflag, entries[1].Action)
+}
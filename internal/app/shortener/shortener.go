@@ -9,6 +9,9 @@ import (
 	"log"
 	"sync"
 
+	"github.com/Fe4p3b/url-shortener/internal/app/canonical"
+	"github.com/Fe4p3b/url-shortener/internal/app/policy"
+	"github.com/Fe4p3b/url-shortener/internal/app/redirect"
 	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
 	"github.com/jackc/pgconn"
@@ -28,40 +31,156 @@ type ShortenerService interface {
 	// if nothing was found the error is returned.
 	Find(string) (*repositories.URL, error)
 
+	// Expand receives shortened URL and returns models.Expansion,
+	// that describes where it leads, deleted URL is reported by
+	// its state, if nothing was found the error is returned.
+	Expand(string) (*models.Expansion, error)
+
 	// Store receives models.URL, generates short URL and tries to save it
-	// in storage, if it can't be stored or short URL can't be created
-	// the error is returned.
+	// in storage, if it can't be stored, short URL can't be created or
+	// options of URL are invalid the error is returned. Invalid URLs
+	// are reported by *ValidationError.
 	Store(*models.URL) (string, error)
 
 	// StoreBatch receives user identificator and repositories.URLs,
 	// generates short URLs and tries to save them in a storage,
 	// if repositories.URLs can't be stored or short URLs can't be created
-	// the error is returned.
+	// the error is returned. Invalid URLs of all repositories.URLs are
+	// reported by single *ValidationError.
 	StoreBatch(string, []repositories.URL) ([]repositories.URL, error)
 
 	// GetUserURLs returns repositories.URLs for user, by user identificator,
 	// or error.
 	GetUserURLs(string) ([]repositories.URL, error)
 
+	// ListUserURLs calls fn for each repositories.URL of user, by user
+	// identificator, URLs are read from storage by pages, so memory
+	// doesn't depend on number of URLs. Error of fn stops listing and
+	// is returned.
+	ListUserURLs(string, func(repositories.URL) error) error
+
+	// GetUserURLsPage returns at most limit repositories.URLs of user,
+	// by user identificator, that go after short URL, and short URL,
+	// that the next page goes after, it is empty for the last page.
+	GetUserURLsPage(string, string, int) ([]repositories.URL, string, error)
+
 	// DeleteURLs deletes URLs for user, by user identificator, asynchronously.
 	DeleteURLs(string, []string)
 
 	// Ping tests connection for the storage, or returns error.
 	Ping() error
 
+	// GetOptions returns options of short URL, that belongs to user,
+	// by user identificator and short URL.
+	GetOptions(string, string) (*models.Options, error)
+
+	// SetOptions validates and replaces options of short URL, that
+	// belongs to user, by user identificator and short URL.
+	SetOptions(string, string, *models.Options) error
+
+	// UpdateOptions validates and replaces options of short URL, that
+	// belongs to user, by user identificator and short URL, by options,
+	// that update sets to the current options, and returns stored
+	// options. Concurrent updates of the short URL aren't lost.
+	UpdateOptions(string, string, func(*models.Options)) (*models.Options, error)
+
+	// AddClick counts visit of short URL, that was served variant.
+	AddClick(string, string) error
+
+	// GetClicks returns number of visits of short URL by variant.
+	GetClicks(string) (map[string]uint, error)
+
+	// ApplyPolicy disables short URLs, that lead to domains, that
+	// aren't allowed by domain policy, and returns their number.
+	ApplyPolicy() (int, error)
+
+	// Report adds abuse report of short URL to moderation queue.
+	Report(*models.Report) error
+
+	// GetReports returns reports of moderation queue by status, or
+	// all reports, if status is empty.
+	GetReports(models.ReportStatus) ([]models.Report, error)
+
+	// Disable disables short URL by moderator with reason, that is
+	// shown to visitors, by short URL, reason and moderator.
+	Disable(string, string, string) error
+
+	// Restore enables short URL, that was disabled, by short URL
+	// and moderator.
+	Restore(string, string) error
+
+	// Flag flags short URL by moderator with reason, so visitors are
+	// warned before redirect, by short URL, reason and moderator.
+	Flag(string, string, string) error
+
+	// Unflag removes flag of moderator from short URL by short URL
+	// and moderator.
+	Unflag(string, string) error
+
+	// GetAuditLog returns audit log of moderation of short URL, or
+	// of all short URLs, if short URL is empty.
+	GetAuditLog(string) ([]models.AuditEntry, error)
+
 	GetStats() (*models.Stats, error)
 }
 
+// ListPageSize is a number of URLs, that are read from storage at
+// once by ListUserURLs.
+const ListPageSize = 100
+
+var ErrorInvalidPageSize = errors.New("page size should be positive")
+
+// PolicyDisabledReason is a reason of short URLs, that are disabled
+// by domain policy.
+const PolicyDisabledReason = "domain policy"
+
 type shortener struct {
 	r       repositories.ShortenerRepository
 	BaseURL string
+
+	// c builds canonical form of original URLs, that is used to
+	// find duplicates, if it is nil original URL is used as is.
+	c *canonical.Canonicalizer
+
+	// policy checks domains of original URLs, if it is nil any
+	// domain is allowed.
+	policy *policy.Policy
+
+	// ownHosts are hosts, that short URLs are served on, URLs, that
+	// lead to them, are rejected to prevent loops.
+	ownHosts []string
+
+	// shortenerHosts are hosts of third-party shorteners, URLs, that
+	// lead to them, are handled by shortenerAction.
+	shortenerHosts  []string
+	shortenerAction ShortenerHostsAction
 }
 
 func NewShortener(r repositories.ShortenerRepository, u string) *shortener {
 	return &shortener{
-		r:       r,
-		BaseURL: u,
+		r:        r,
+		BaseURL:  u,
+		ownHosts: normalizeHosts([]string{hostname(u)}),
+	}
+}
+
+// SetCanonicalizer sets canonicalizer of original URLs.
+func (s *shortener) SetCanonicalizer(c *canonical.Canonicalizer) {
+	s.c = c
+}
+
+// SetPolicy sets domain policy, that original URLs are checked by.
+func (s *shortener) SetPolicy(p *policy.Policy) {
+	s.policy = p
+}
+
+// canonicalize returns canonical form of original URL.
+func (s *shortener) canonicalize(u string) (string, error) {
+	if s.c == nil {
+		return u, nil
 	}
+
+	return s.c.Canonicalize(u)
 }
 
 // Find implements ShortenerService Find method.
@@ -69,12 +188,69 @@ func (s *shortener) Find(url string) (*repositories.URL, error) {
 	return s.r.Find(url)
 }
 
+// Expand implements ShortenerService Expand method.
+func (s *shortener) Expand(shortURL string) (*models.Expansion, error) {
+	u, err := s.r.Find(shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &models.Expansion{
+		ShortURL: fmt.Sprintf("%s/%s", s.BaseURL, shortURL),
+		State:    models.LinkActive,
+	}
+
+	if !u.CreatedAt.IsZero() {
+		createdAt := u.CreatedAt.UTC()
+		e.CreatedAt = &createdAt
+	}
+
+	if u.IsDeleted {
+		e.State = models.LinkDeleted
+		return e, nil
+	}
+
+	if u.DisabledReason != "" {
+		e.State = models.LinkDisabled
+		e.Reason = u.DisabledReason
+		return e, nil
+	}
+
+	e.URL = u.URL
+	e.Title = u.Title
+	e.Flags = u.Flags
+	return e, nil
+}
+
 // Store implements ShortenerService Store method.
 // The method generates short URL using "github.com/teris-io/shortid"
 // package.
-// If URL can't be saved, due to already being stored in the storage
-// and postgres is used as a storage, it returns already existing URL.
+// If URL can't be saved, due to URL with the same canonical form
+// already being stored in the storage and postgres is used as
+// a storage, it returns already existing URL.
 func (s *shortener) Store(url *models.URL) (string, error) {
+	e := &ValidationError{}
+	s.validateDestinations(e, "", "url", url.URL, &url.Options)
+	if err := e.err(); err != nil {
+		return "", err
+	}
+	url.URL = s.normalizeDestinations(url.URL, &url.Options)
+
+	err := redirect.Validate(&url.Options)
+	if err != nil {
+		return "", err
+	}
+
+	url.CanonicalURL, err = s.canonicalize(url.URL)
+	if err != nil {
+		return "", err
+	}
+
+	e.add("url", canonicalLength(url.CanonicalURL))
+	if err := e.err(); err != nil {
+		return "", err
+	}
+
 	uuid, err := shortid.Generate()
 	if err != nil {
 		return "", err
@@ -98,15 +274,174 @@ func (s *shortener) GetUserURLs(user string) ([]repositories.URL, error) {
 	return s.r.GetUserURLs(user, s.BaseURL)
 }
 
+// ListUserURLs implements ShortenerService ListUserURLs method.
+func (s *shortener) ListUserURLs(user string, fn func(repositories.URL) error) error {
+	after := ""
+	for {
+		URLs, next, err := s.GetUserURLsPage(user, after, ListPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range URLs {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		after = next
+	}
+}
+
+// GetUserURLsPage implements ShortenerService GetUserURLsPage method.
+// One more URL is read to find out, whether the next page exists.
+func (s *shortener) GetUserURLsPage(user string, after string, limit int) ([]repositories.URL, string, error) {
+	if limit <= 0 {
+		return nil, "", ErrorInvalidPageSize
+	}
+
+	URLs, err := s.r.GetUserURLsPage(user, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(URLs) > limit {
+		URLs = URLs[:limit]
+		next = URLs[limit-1].ShortURL
+	}
+
+	for i := range URLs {
+		URLs[i].ShortURL = fmt.Sprintf("%s/%s", s.BaseURL, URLs[i].ShortURL)
+	}
+
+	return URLs, next, nil
+}
+
 // Ping implements ShortenerService Ping method.
 func (s *shortener) Ping() error {
 	return s.r.Ping()
 }
 
+// GetOptions implements ShortenerService GetOptions method.
+func (s *shortener) GetOptions(user string, shortURL string) (*models.Options, error) {
+	return s.r.GetOptions(shortURL, user)
+}
+
+// SetOptions implements ShortenerService SetOptions method.
+func (s *shortener) SetOptions(user string, shortURL string, o *models.Options) error {
+	stored, err := s.UpdateOptions(user, shortURL, func(current *models.Options) {
+		*current = *o
+	})
+	if err != nil {
+		return err
+	}
+
+	*o = *stored
+	return nil
+}
+
+// UpdateOptions implements ShortenerService UpdateOptions method.
+// Flags are set by destinations, flag of moderator is kept.
+func (s *shortener) UpdateOptions(user string, shortURL string, update func(*models.Options)) (*models.Options, error) {
+	var stored models.Options
+	err := s.r.UpdateOptions(shortURL, user, func(u *repositories.URL) error {
+		o := u.Options
+		update(&o)
+
+		e := &ValidationError{}
+		s.validateOptions(e, "", &o)
+		if err := e.err(); err != nil {
+			return err
+		}
+
+		s.normalizeDestinations(u.URL, &o)
+		if hasFlag(u.Flags, FlagModerator) {
+			o.Flags = append(o.Flags, FlagModerator)
+		}
+
+		if err := redirect.Validate(&o); err != nil {
+			return err
+		}
+
+		u.Options = o
+		stored = o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// AddClick implements ShortenerService AddClick method.
+func (s *shortener) AddClick(shortURL string, variant string) error {
+	return s.r.AddClick(shortURL, variant)
+}
+
+// GetClicks implements ShortenerService GetClicks method.
+func (s *shortener) GetClicks(shortURL string) (map[string]uint, error) {
+	return s.r.GetClicks(shortURL)
+}
+
+// ApplyPolicy implements ShortenerService ApplyPolicy method.
+// Original URL and URLs of options of short URLs are checked.
+func (s *shortener) ApplyPolicy() (int, error) {
+	if s.policy == nil {
+		return 0, nil
+	}
+
+	URLs, err := s.r.GetEnabledURLs()
+	if err != nil {
+		return 0, err
+	}
+
+	var disabled []string
+	for _, u := range URLs {
+		if !s.allowedByPolicy(u.URL, &u.Options) {
+			disabled = append(disabled, u.ShortURL)
+		}
+	}
+
+	if err := s.r.DisableURLs(PolicyDisabledReason, disabled); err != nil {
+		return 0, err
+	}
+
+	return len(disabled), nil
+}
+
 // StoreBatch implements ShortenerService StoreBatch method.
 // To optimize performance the method populates buffer of a storage,
 // when buffer capacity is reached it saves all the URLs in buffer.
 func (s *shortener) StoreBatch(user string, urls []repositories.URL) (batch []repositories.URL, err error) {
+	e := &ValidationError{}
+	for i, v := range urls {
+		s.validateDestinations(e, fmt.Sprintf("[%d].", i), "original_url", v.URL, &v.Options)
+	}
+	if err := e.err(); err != nil {
+		return nil, err
+	}
+
+	for i := range urls {
+		urls[i].URL = s.normalizeDestinations(urls[i].URL, &urls[i].Options)
+		if err := redirect.Validate(&urls[i].Options); err != nil {
+			return nil, err
+		}
+
+		urls[i].CanonicalURL, err = s.canonicalize(urls[i].URL)
+		if err != nil {
+			return nil, err
+		}
+		e.add(fmt.Sprintf("[%d].original_url", i), canonicalLength(urls[i].CanonicalURL))
+	}
+	if err := e.err(); err != nil {
+		return nil, err
+	}
+
 	for _, v := range urls {
 		uuid, err := shortid.Generate()
 		if err != nil {
@@ -120,6 +455,8 @@ func (s *shortener) StoreBatch(user string, urls []repositories.URL) (batch []re
 		}
 
 		v.URL = ""
+		v.CanonicalURL = ""
+		v.Options = models.Options{}
 		v.ShortURL = fmt.Sprintf("%s/%s", s.BaseURL, uuid)
 		batch = append(batch, v)
 	}
@@ -1,9 +1,18 @@
 package shortener
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Fe4p3b/url-shortener/internal/app/canonical"
+	"github.com/Fe4p3b/url-shortener/internal/app/idn"
+	"github.com/Fe4p3b/url-shortener/internal/app/policy"
 	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
 	"github.com/Fe4p3b/url-shortener/internal/storage"
@@ -88,12 +97,12 @@ func Test_shortener_Store(t *testing.T) {
 				r: storage,
 			},
 			urls: []models.URL{
-				{URL: "google.com"},
-				{URL: "yandex.ru"},
-				{URL: "yahoo.com"},
-				{URL: "google.com"},
-				{URL: "yandex.ru"},
-				{URL: "yahoo.com"},
+				{URL: "https://google.com"},
+				{URL: "https://yandex.ru"},
+				{URL: "https://yahoo.com"},
+				{URL: "https://google.com"},
+				{URL: "https://yandex.ru"},
+				{URL: "https://yahoo.com"},
 			},
 			wantErr: false,
 		},
@@ -180,6 +189,91 @@ func Test_shortener_GetUserURLs(t *testing.T) {
 	}
 }
 
+func Test_shortener_ListUserURLs(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	for i := 0; i < ListPageSize*2+5; i++ {
+		m.S[fmt.Sprintf("u%03d", i)] = "https://yandex.ru"
+		m.Restore(fmt.Sprintf("u%03d", i), "user", models.Options{}, time.Time{})
+	}
+	m.S["other"] = "https://yandex.ru"
+	m.Restore("other", "other", models.Options{}, time.Time{})
+
+	tests := []struct {
+		name  string
+		user  string
+		stop  int
+		count int
+	}{
+		{name: "Test case #1", user: "user", count: ListPageSize*2 + 5},
+		{name: "Test case #2", user: "other", count: 1},
+		{name: "Test case #3", user: "nobody", count: 0},
+		{name: "Test case #4", user: "user", stop: 150, count: 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewShortener(m, "http://localhost:8080")
+			errStop := errors.New("stop")
+
+			var URLs []string
+			err := s.ListUserURLs(tt.user, func(u repositories.URL) error {
+				URLs = append(URLs, u.ShortURL)
+				if len(URLs) == tt.stop {
+					return errStop
+				}
+				return nil
+			})
+			if tt.stop > 0 {
+				assert.ErrorIs(t, err, errStop)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, URLs, tt.count)
+			assert.True(t, sort.StringsAreSorted(URLs))
+			for _, u := range URLs {
+				assert.True(t, strings.HasPrefix(u, "http://localhost:8080/"))
+			}
+		})
+	}
+}
+
+func Test_shortener_GetUserURLsPage(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	for _, u := range []string{"a", "b", "c"} {
+		m.S[u] = "https://yandex.ru"
+		m.Restore(u, "user", models.Options{}, time.Time{})
+	}
+
+	tests := []struct {
+		name    string
+		after   string
+		limit   int
+		want    []string
+		next    string
+		wantErr error
+	}{
+		{name: "Test case #1", limit: 2, want: []string{"http://localhost:8080/a", "http://localhost:8080/b"}, next: "b"},
+		{name: "Test case #2", after: "b", limit: 2, want: []string{"http://localhost:8080/c"}},
+		{name: "Test case #3", limit: 0, wantErr: ErrorInvalidPageSize},
+		{name: "Test case #4", limit: -1, wantErr: ErrorInvalidPageSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewShortener(m, "http://localhost:8080")
+
+			URLs, next, err := s.GetUserURLsPage("user", tt.after, tt.limit)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			var got []string
+			for _, u := range URLs {
+				got = append(got, u.ShortURL)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.next, next)
+		})
+	}
+}
+
 func Test_shortener_StoreBatch(t *testing.T) {
 	s := memory.NewMemory(
 		map[string]string{
@@ -239,3 +333,153 @@ func Test_shortener_DeleteURLs(t *testing.T) {
 		})
 	}
 }
+
+func Test_shortener_Expand(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	err := m.Save(&models.URL{URL: "https://yandex.ru", ShortURL: "asdf", Options: models.Options{Title: "Yandex"}})
+	assert.NoError(t, err)
+
+	s := NewShortener(m, "http://localhost:8080")
+
+	got, err := s.Expand("asdf")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/asdf", got.ShortURL)
+	assert.Equal(t, "https://yandex.ru", got.URL)
+	assert.Equal(t, "Yandex", got.Title)
+	assert.Equal(t, models.LinkActive, got.State)
+	assert.NotNil(t, got.CreatedAt)
+
+	_, err = s.Expand("qwerty")
+	assert.ErrorIs(t, err, storage.ErrorNoLinkFound)
+}
+
+func Test_shortener_Store_Canonical(t *testing.T) {
+	c, err := canonical.New(canonical.DefaultSteps)
+	assert.NoError(t, err)
+
+	s := NewShortener(memory.NewMemory(map[string]string{}), "http://localhost:8080")
+	s.SetCanonicalizer(c)
+
+	u := &models.URL{URL: "HTTP://Example.com:80/a?b=1&a=2"}
+	_, err = s.Store(u)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP://Example.com:80/a?b=1&a=2", u.URL)
+	assert.Equal(t, "http://example.com/a?a=2&b=1", u.CanonicalURL)
+}
+
+func Test_shortener_Policy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny")
+	err := os.WriteFile(path, []byte("phishing.com\n"), 0644)
+	assert.NoError(t, err)
+
+	p, err := policy.New("", path)
+	assert.NoError(t, err)
+
+	m := memory.NewMemory(map[string]string{})
+	s := NewShortener(m, "http://localhost:8080")
+	s.SetPolicy(p)
+
+	_, err = s.Store(&models.URL{URL: "https://login.phishing.com/bank"})
+	assert.ErrorIs(t, err, ErrorInvalidURL)
+
+	_, err = s.Store(&models.URL{URL: "https://yandex.ru", ShortURL: "asdf"})
+	assert.NoError(t, err)
+
+	err = os.WriteFile(path, []byte("phishing.com\nyandex.ru\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Reload())
+
+	n, err := s.ApplyPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	URLs, err := m.GetEnabledURLs()
+	assert.NoError(t, err)
+	assert.Empty(t, URLs)
+}
+
+func Test_shortener_Policy_Unicode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny")
+	err := os.WriteFile(path, []byte("xn--e1afmkfd.xn--p1ai\n"), 0644)
+	assert.NoError(t, err)
+
+	p, err := policy.New("", path)
+	assert.NoError(t, err)
+
+	s := NewShortener(memory.NewMemory(map[string]string{}), "http://localhost:8080")
+	s.SetPolicy(p)
+
+	_, err = s.Store(&models.URL{URL: "https://пример.рф/login"})
+	assert.ErrorIs(t, err, ErrorInvalidURL)
+
+	_, err = s.Store(&models.URL{URL: "https://yandex.ru", Options: models.Options{Variants: []models.Variant{{Name: "a", URL: "https://ПРИМЕР.рф", Weight: 1}}}})
+	assert.ErrorIs(t, err, ErrorInvalidURL)
+}
+
+func Test_shortener_Store_Homograph(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	s := NewShortener(m, "http://localhost:8080")
+
+	u := &models.URL{URL: "https://аpple.com/login"}
+	_, err := s.Store(u)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://xn--pple-43d.com/login", u.URL)
+	assert.Equal(t, []string{idn.FlagConfusable, idn.FlagMixedScript}, u.Flags)
+
+	e, err := s.Expand(u.ShortURL)
+	assert.NoError(t, err)
+	assert.Equal(t, u.Flags, e.Flags)
+
+	u = &models.URL{URL: "https://пример.рф", UserID: "user"}
+	_, err = s.Store(u)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://xn--e1afmkfd.xn--p1ai", u.URL)
+	assert.Empty(t, u.Flags)
+
+	err = s.SetOptions("user", u.ShortURL, &models.Options{Variants: []models.Variant{{Name: "a", URL: "https://gооgle.com", Weight: 1}, {Name: "b", URL: "https://google.com", Weight: 1}}})
+	assert.NoError(t, err)
+
+	o, err := m.GetOptions(u.ShortURL, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://xn--ggle-55da.com", o.Variants[0].URL)
+	assert.Equal(t, []string{idn.FlagConfusable, idn.FlagMixedScript}, o.Flags)
+}
+
+func Test_shortener_Hosts(t *testing.T) {
+	tests := []struct {
+		name   string
+		action ShortenerHostsAction
+		url    string
+		err    bool
+		flags  []string
+	}{
+		{name: "Test case #1", action: ShortenerHostsFlag, url: "https://short.example.com/asdf", err: true},
+		{name: "Test case #2", action: ShortenerHostsFlag, url: "https://SHORT.example.com./asdf", err: true},
+		{name: "Test case #3", action: ShortenerHostsFlag, url: "https://go.example.org/asdf", err: true},
+		{name: "Test case #4", action: ShortenerHostsFlag, url: "https://www.example.com/", err: false},
+		{name: "Test case #5", action: ShortenerHostsFlag, url: "https://bit.ly/asdf", err: false, flags: []string{FlagShortener}},
+		{name: "Test case #6", action: ShortenerHostsFlag, url: "https://www.bit.ly/asdf", err: false, flags: []string{FlagShortener}},
+		{name: "Test case #7", action: ShortenerHostsReject, url: "https://bit.ly/asdf", err: true},
+		{name: "Test case #8", action: ShortenerHostsReject, url: "https://notbit.ly/asdf", err: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewShortener(memory.NewMemory(map[string]string{}), "https://short.example.com")
+			s.SetCustomDomains([]string{"go.example.org"})
+			assert.NoError(t, s.SetShortenerHosts(DefaultShortenerHosts, tt.action))
+
+			u := &models.URL{URL: tt.url}
+			_, err := s.Store(u)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrorInvalidURL)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.flags, u.Flags)
+		})
+	}
+
+	s := NewShortener(memory.NewMemory(map[string]string{}), "https://short.example.com")
+	assert.ErrorIs(t, s.SetShortenerHosts(nil, "ignore"), ErrorUnknownShortenerHostsAction)
+}
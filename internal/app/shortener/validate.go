@@ -0,0 +1,306 @@
+package shortener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/idn"
+	"github.com/Fe4p3b/url-shortener/internal/models"
+)
+
+const (
+	// MaxURLLength is a maximum length of original URL, it matches
+	// length of original_url column of storage.
+	MaxURLLength = 255
+
+	// maxHostLength is a maximum length of host name.
+	maxHostLength = 253
+
+	// maxLabelLength is a maximum length of a label of host name.
+	maxLabelLength = 63
+)
+
+var ErrorInvalidURL = errors.New("invalid URL")
+
+// FieldError describes invalid field of a request.
+type FieldError struct {
+	// Field is a path of field, like "url" or "[1].targeting[0].url".
+	Field string `json:"field"`
+
+	// Message describes why value of field is invalid.
+	Message string `json:"message"`
+}
+
+// ValidationError is returned, when URLs of a request are invalid,
+// it contains error of each invalid field.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, f := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+
+	return fmt.Sprintf("%v: %s", ErrorInvalidURL, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrorInvalidURL
+}
+
+// add adds error of field, if message is not empty.
+func (e *ValidationError) add(field string, message string) {
+	if message != "" {
+		e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+	}
+}
+
+// err returns e, if it has errors, or nil.
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// validateDestinations checks original URL and URLs of options, that
+// visitors can be redirected to. Fields are prefixed by prefix.
+func (s *shortener) validateDestinations(e *ValidationError, prefix string, field string, u string, o *models.Options) {
+	e.add(prefix+field, s.checkDestination(u))
+	s.validateOptions(e, prefix, o)
+}
+
+// validateOptions checks URLs of options, that visitors can be
+// redirected to. Fields are prefixed by prefix.
+func (s *shortener) validateOptions(e *ValidationError, prefix string, o *models.Options) {
+	for i, r := range o.Targeting {
+		e.add(fmt.Sprintf("%stargeting[%d].url", prefix, i), s.checkDestination(r.URL))
+	}
+
+	for i, r := range o.Geo {
+		e.add(fmt.Sprintf("%sgeo[%d].url", prefix, i), s.checkDestination(r.URL))
+	}
+
+	for i, v := range o.Variants {
+		e.add(fmt.Sprintf("%svariants[%d].url", prefix, i), s.checkDestination(v.URL))
+	}
+}
+
+// checkDestination returns why u can't be a destination of redirect
+// by checkURL, by its host or by domain policy, or empty string, if u
+// is valid. Domain policy checks ASCII form of host, that is stored.
+func (s *shortener) checkDestination(u string) string {
+	if m := checkURL(u); m != "" {
+		return m
+	}
+
+	if m := s.checkHost(u); m != "" {
+		return m
+	}
+
+	if s.policy == nil {
+		return ""
+	}
+
+	if err := s.policy.Check(normalizeHost(hostname(u))); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// allowedByPolicy checks domains of original URL and URLs of options
+// by domain policy only, so URLs, that were stored before validation
+// was added, aren't disabled.
+func (s *shortener) allowedByPolicy(u string, o *models.Options) bool {
+	for _, d := range destinations(u, o) {
+		if err := s.policy.Check(normalizeHost(hostname(d))); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// destinations returns original URL and URLs of options, that
+// visitors can be redirected to.
+func destinations(u string, o *models.Options) []string {
+	d := []string{u}
+	for _, r := range o.Targeting {
+		d = append(d, r.URL)
+	}
+	for _, r := range o.Geo {
+		d = append(d, r.URL)
+	}
+	for _, v := range o.Variants {
+		d = append(d, v.URL)
+	}
+
+	return d
+}
+
+// normalizeDestinations converts hosts of valid original URL u and
+// URLs of options to punycode, sets flags of suspicious hosts to
+// options and returns converted original URL.
+func (s *shortener) normalizeDestinations(u string, o *models.Options) string {
+	for i := range o.Targeting {
+		o.Targeting[i].URL = asciiURL(o.Targeting[i].URL)
+	}
+	for i := range o.Geo {
+		o.Geo[i].URL = asciiURL(o.Geo[i].URL)
+	}
+	for i := range o.Variants {
+		o.Variants[i].URL = asciiURL(o.Variants[i].URL)
+	}
+	u = asciiURL(u)
+
+	set := make(map[string]struct{})
+	for _, d := range destinations(u, o) {
+		for _, f := range idn.Check(hostname(d)) {
+			set[f] = struct{}{}
+		}
+
+		if s.shortenerAction == ShortenerHostsFlag && s.isShortenerHost(normalizeHost(hostname(d))) {
+			set[FlagShortener] = struct{}{}
+		}
+	}
+
+	o.Flags = nil
+	for f := range set {
+		o.Flags = append(o.Flags, f)
+	}
+	sort.Strings(o.Flags)
+
+	return u
+}
+
+// asciiURL returns valid URL u with internationalized host converted
+// to punycode, URL with ASCII host is returned as is.
+func asciiURL(u string) string {
+	p, err := url.Parse(u)
+	if err != nil || idn.IsASCII(p.Hostname()) {
+		return u
+	}
+
+	host, err := idn.ToASCII(p.Hostname())
+	if err != nil {
+		return u
+	}
+
+	if port := p.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	p.Host = host
+
+	return p.String()
+}
+
+// hostname returns host name of valid URL u.
+func hostname(u string) string {
+	p, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+
+	return p.Hostname()
+}
+
+// checkURL returns why u can't be a destination of redirect, or
+// empty string, if u is valid.
+func checkURL(u string) string {
+	if u == "" {
+		return "is required"
+	}
+
+	p, err := url.Parse(u)
+	if err != nil {
+		return "is not a valid URL"
+	}
+
+	if p.Scheme != "" && p.Scheme != "http" && p.Scheme != "https" {
+		return fmt.Sprintf("scheme %q is not allowed, use http or https", p.Scheme)
+	}
+
+	if !p.IsAbs() || p.Opaque != "" {
+		return "should be an absolute URL"
+	}
+
+	if p.Host == "" {
+		return "host is required"
+	}
+
+	host := p.Hostname()
+	if net.ParseIP(host) == nil {
+		host, err = idn.ToASCII(host)
+	}
+	if err != nil || !isValidHost(host) {
+		return fmt.Sprintf("host %q is not valid", p.Hostname())
+	}
+
+	return checkLength(asciiURL(u))
+}
+
+// canonicalLength returns why canonical form c of original URL is too
+// long to be stored, or empty string.
+func canonicalLength(c string) string {
+	if m := checkLength(c); m != "" {
+		return "canonical form " + m
+	}
+
+	return ""
+}
+
+// checkLength returns why URL u in the form, that is stored, is too
+// long, or empty string, if it fits storage.
+func checkLength(u string) string {
+	if utf8.RuneCountInString(u) > MaxURLLength {
+		return fmt.Sprintf("should be at most %d characters", MaxURLLength)
+	}
+
+	return ""
+}
+
+// isValidHost checks whether host is an IP address or a fully
+// qualified domain name in ASCII form.
+func isValidHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > maxHostLength || !strings.Contains(host, ".") {
+		return false
+	}
+
+	labels := strings.Split(host, ".")
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return false
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > maxLabelLength {
+			return false
+		}
+
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+
+		for _, c := range label {
+			if c != '-' && (c > unicode.MaxASCII || !unicode.IsLetter(c) && !unicode.IsDigit(c)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,92 @@
+package shortener
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/canonical"
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/Fe4p3b/url-shortener/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		valid bool
+	}{
+		{name: "Test case #1", url: "https://yandex.ru/path?q=1#top", valid: true},
+		{name: "Test case #2", url: "http://127.0.0.1:8080/", valid: true},
+		{name: "Test case #3", url: "http://[::1]/", valid: true},
+		{name: "Test case #4", url: "https://пример.рф", valid: true},
+		{name: "Test case #5", url: "https://example.com.", valid: true},
+		{name: "Test case #6", url: "", valid: false},
+		{name: "Test case #7", url: "javascript:alert(1)", valid: false},
+		{name: "Test case #8", url: "data:text/html,<script>", valid: false},
+		{name: "Test case #9", url: "file:///etc/passwd", valid: false},
+		{name: "Test case #10", url: "/relative/path", valid: false},
+		{name: "Test case #11", url: "ftp://example.com", valid: false},
+		{name: "Test case #12", url: "https://localhost", valid: false},
+		{name: "Test case #13", url: "https://exa_mple.com", valid: false},
+		{name: "Test case #14", url: "https://-example.com", valid: false},
+		{name: "Test case #15", url: "https://999.1.1.1", valid: false},
+		{name: "Test case #16", url: "https://example.com/" + strings.Repeat("a", MaxURLLength), valid: false},
+		{name: "Test case #17", url: "https:///path", valid: false},
+		{name: "Test case #18", url: "http://%zz", valid: false},
+		{name: "Test case #19", url: "https://пример.рф/" + strings.Repeat("я", 100), valid: false},
+		{name: "Test case #20", url: "https://" + strings.Repeat("яяяяяяяяяя.", 8) + "рф/" + strings.Repeat("a", 120), valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkURL(tt.url)
+			if tt.valid {
+				assert.Empty(t, got)
+			} else {
+				assert.NotEmpty(t, got)
+			}
+		})
+	}
+}
+
+func Test_shortener_StoreBatch_Validation(t *testing.T) {
+	s := NewShortener(memory.NewMemory(map[string]string{}), "http://localhost:8080")
+
+	_, err := s.StoreBatch("user", []repositories.URL{
+		{URL: "https://yandex.ru"},
+		{URL: "yandex.ru"},
+		{
+			URL: "https://yandex.ru",
+			Options: models.Options{
+				Targeting: []models.TargetingRule{{Platform: models.PlatformIOS, URL: "javascript:alert(1)"}},
+			},
+		},
+	})
+
+	var vErr *ValidationError
+	assert.ErrorAs(t, err, &vErr)
+	assert.ErrorIs(t, err, ErrorInvalidURL)
+	assert.Equal(t, []FieldError{
+		{Field: "[1].original_url", Message: "should be an absolute URL"},
+		{Field: "[2].targeting[0].url", Message: `scheme "javascript" is not allowed, use http or https`},
+	}, vErr.Errors)
+}
+
+func Test_shortener_Store_CanonicalLength(t *testing.T) {
+	s := NewShortener(memory.NewMemory(map[string]string{}), "http://localhost:8080")
+	c, err := canonical.New(canonical.DefaultSteps)
+	assert.NoError(t, err)
+	s.SetCanonicalizer(c)
+
+	long := "https://example.com/" + strings.Repeat("я", 100)
+
+	_, err = s.Store(&models.URL{URL: long, UserID: "user"})
+	var vErr *ValidationError
+	assert.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []FieldError{{Field: "url", Message: "canonical form should be at most 255 characters"}}, vErr.Errors)
+
+	_, err = s.StoreBatch("user", []repositories.URL{{URL: "https://yandex.ru"}, {URL: long}})
+	assert.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []FieldError{{Field: "[1].original_url", Message: "canonical form should be at most 255 characters"}}, vErr.Errors)
+}
@@ -0,0 +1,145 @@
+// Package geoip provides lookup of visitor's country in a local
+// MaxMind database file.
+package geoip
+
+import (
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DefaultReloadInterval is an interval of database file checks
+// for changes.
+const DefaultReloadInterval = 30 * time.Second
+
+// Locator finds country of IP address.
+type Locator interface {
+	// Country returns ISO 3166-1 alpha-2 code of IP address country,
+	// or empty string, if country is unknown.
+	Country(ip net.IP) (string, error)
+}
+
+// DB is a MaxMind database, that is reloaded, when its file changes.
+type DB struct {
+	sync.RWMutex
+
+	// path is a path of database file.
+	path string
+
+	// modTime and size of database file, that is loaded.
+	modTime time.Time
+	size    int64
+
+	reader *maxminddb.Reader
+	done   chan struct{}
+}
+
+// record is a part of database record, that is used for lookup.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+var _ Locator = &DB{}
+
+// NewDB opens database file. If interval is positive, the file
+// is checked for changes with the interval and is reloaded.
+func NewDB(path string, interval time.Duration) (*DB, error) {
+	db := &DB{
+		path: path,
+		done: make(chan struct{}),
+	}
+
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go db.watch(interval)
+	}
+
+	return db, nil
+}
+
+// Reload opens database file again, if it was changed since it
+// was loaded.
+func (db *DB) Reload() error {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return err
+	}
+
+	db.RLock()
+	changed := db.reader == nil || !info.ModTime().Equal(db.modTime) || info.Size() != db.size
+	db.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	reader, err := maxminddb.Open(db.path)
+	if err != nil {
+		return err
+	}
+
+	db.Lock()
+	old := db.reader
+	db.reader = reader
+	db.modTime = info.ModTime()
+	db.size = info.Size()
+	db.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+
+	return nil
+}
+
+// watch reloads database with interval, until database is closed.
+func (db *DB) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.done:
+			return
+		case <-ticker.C:
+			if err := db.Reload(); err != nil {
+				log.Printf("error reloading geoip database: %v", err)
+			}
+		}
+	}
+}
+
+// Country implements Locator Country method.
+func (db *DB) Country(ip net.IP) (string, error) {
+	if ip == nil {
+		return "", nil
+	}
+
+	db.RLock()
+	defer db.RUnlock()
+
+	var r record
+	if err := db.reader.Lookup(ip, &r); err != nil {
+		return "", err
+	}
+
+	return r.Country.ISOCode, nil
+}
+
+// Close stops reloading and closes database.
+func (db *DB) Close() error {
+	close(db.done)
+
+	db.Lock()
+	defer db.Unlock()
+
+	return db.reader.Close()
+}
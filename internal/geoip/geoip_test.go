@@ -0,0 +1,91 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func copyFile(t *testing.T, src string, dst string) {
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dst, data, 0644))
+}
+
+func TestDB_Country(t *testing.T) {
+	db, err := NewDB("testdata/country-test.mmdb", 0)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tests := []struct {
+		name string
+		ip   net.IP
+		want string
+	}{
+		{
+			name: "Test case #1",
+			ip:   net.ParseIP("81.2.69.142"),
+			want: "GB",
+		},
+		{
+			name: "Test case #2",
+			ip:   net.ParseIP("2.72.10.1"),
+			want: "KZ",
+		},
+		{
+			name: "Test case #3",
+			ip:   net.ParseIP("2001:db8::1"),
+			want: "DE",
+		},
+		{
+			name: "Test case #4",
+			ip:   net.ParseIP("127.0.0.1"),
+			want: "",
+		},
+		{
+			name: "Test case #5",
+			ip:   nil,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.Country(tt.ip)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDB_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "country.mmdb")
+	copyFile(t, "testdata/country-test.mmdb", path)
+
+	db, err := NewDB(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ip := net.ParseIP("81.2.69.142")
+	got, err := db.Country(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "GB", got)
+
+	copyFile(t, "testdata/country-test-updated.mmdb", path)
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	assert.Eventually(t, func() bool {
+		got, err := db.Country(ip)
+		return err == nil && got == "IE"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNewDB(t *testing.T) {
+	_, err := NewDB("testdata/missing.mmdb", 0)
+	assert.Error(t, err)
+}
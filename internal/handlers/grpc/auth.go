@@ -0,0 +1,158 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/auth"
+	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// TokenKey is a key of metadata, that keeps encrypted token of user,
+// it is the same token, that HTTP AuthMiddleware passes in a cookie.
+const TokenKey = "token"
+
+// publicMethods are methods, that don't need user, so new user isn't
+// created for requests without token. Health checks and reflection
+// are public as well.
+var publicMethods = map[string]struct{}{
+	fullMethod("GetURL"):    {},
+	fullMethod("ExpandURL"): {},
+	fullMethod("Ping"):      {},
+	fullMethod("GetStats"):  {},
+
+	fullMethodV2("GetURL"):    {},
+	fullMethodV2("ExpandURL"): {},
+	fullMethodV2("GetStats"):  {},
+
+	"/" + healthpb.Health_ServiceDesc.ServiceName + "/Check":                              {},
+	"/" + healthpb.Health_ServiceDesc.ServiceName + "/Watch":                              {},
+	"/" + reflectionpb.ServerReflection_ServiceDesc.ServiceName + "/ServerReflectionInfo": {},
+}
+
+// AuthInterceptor authenticates user by token in metadata. If request
+// has no token, new user is created and its token is sent back in
+// header metadata, as AuthMiddleware does with a cookie.
+type AuthInterceptor struct {
+	// auth is a service that performs operations on
+	// encryption, decryption and authentication.
+	auth auth.AuthService
+}
+
+func NewAuthInterceptor(auth auth.AuthService) *AuthInterceptor {
+	return &AuthInterceptor{auth: auth}
+}
+
+// Unary is an interceptor of unary calls.
+func (a *AuthInterceptor) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod, func(md metadata.MD) error {
+		return grpc.SetHeader(ctx, md)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// Stream is an interceptor of streaming calls.
+func (a *AuthInterceptor) Stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod, ss.SetHeader)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate returns context with user, that is authenticated by
+// token, or with new user, whose token is sent by setHeader.
+func (a *AuthInterceptor) authenticate(ctx context.Context, method string, setHeader func(metadata.MD) error) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if token := md.Get(TokenKey); len(token) > 0 {
+		user, err := a.auth.Decrypt(token[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		if err := a.auth.VerifyUser(string(user)); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, status.Error(codes.Unauthenticated, "unknown user")
+			}
+
+			log.Printf("grpc: verify user: %v", err)
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+
+		return context.WithValue(ctx, middleware.Key, string(user)), nil
+	}
+
+	if _, ok := publicMethods[method]; ok {
+		return ctx, nil
+	}
+
+	uuid, err := a.auth.CreateUser()
+	if err != nil {
+		log.Printf("grpc: create user: %v", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	token, err := a.auth.Encrypt(uuid)
+	if err != nil {
+		log.Printf("grpc: encrypt token: %v", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	if err := setHeader(metadata.Pairs(TokenKey, token)); err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(ctx, middleware.Key, uuid), nil
+}
+
+// authStream is a server stream with context, that keeps user.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// userFromContext returns user, that was authenticated by
+// AuthInterceptor. User of request body is accepted only, if it is
+// empty or matches authenticated user.
+func userFromContext(ctx context.Context, bodyUser string) (string, error) {
+	user, ok := ctx.Value(middleware.Key).(string)
+	if !ok || user == "" {
+		return "", status.Error(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	if bodyUser != "" && bodyUser != user {
+		return "", status.Error(codes.PermissionDenied, "user of request doesn't match token")
+	}
+
+	return user, nil
+}
+
+// fullMethod returns full name of method of Shortener service.
+func fullMethod(name string) string {
+	return "/" + pb.Shortener_ServiceDesc.ServiceName + "/" + name
+}
+
+// fullMethodV2 returns full name of method of shortener.v2.Shortener
+// service.
+func fullMethodV2(name string) string {
+	return "/" + pbv2.Shortener_ServiceDesc.ServiceName + "/" + name
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/auth"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeAuth is an auth.AuthService, where token is a user.
+type fakeAuth struct {
+	users map[string]bool
+}
+
+var _ auth.AuthService = &fakeAuth{}
+
+func (a *fakeAuth) CreateUser() (string, error) {
+	return "new", nil
+}
+
+func (a *fakeAuth) Encrypt(src string) (string, error) {
+	return "token-" + src, nil
+}
+
+func (a *fakeAuth) Decrypt(src string) ([]byte, error) {
+	if src == "broken" {
+		return nil, errors.New("message authentication failed")
+	}
+	return []byte(src), nil
+}
+
+func (a *fakeAuth) VerifyUser(user string) error {
+	if !a.users[user] {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// headerStream is a server transport stream, that keeps header.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string {
+	return ""
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestAuthInterceptor_Unary(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		user   string
+		header string
+		code   codes.Code
+	}{
+		{name: "Test case #1", method: "PostURL", token: "user", user: "user", code: codes.OK},
+		{name: "Test case #2", method: "PostURL", user: "new", header: "token-new", code: codes.OK},
+		{name: "Test case #3", method: "GetURL", code: codes.OK},
+		{name: "Test case #4", method: "PostURL", token: "broken", code: codes.Unauthenticated},
+		{name: "Test case #5", method: "PostURL", token: "unknown", code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthInterceptor(&fakeAuth{users: map[string]bool{"user": true}})
+
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(TokenKey, tt.token))
+			}
+			stream := &headerStream{}
+			ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+			var user string
+			_, err := a.Unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod(tt.method)}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				user, _ = ctx.Value(middleware.Key).(string)
+				return nil, nil
+			})
+
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.user, user)
+			if tt.header != "" {
+				assert.Equal(t, []string{tt.header}, stream.header.Get(TokenKey))
+			}
+		})
+	}
+}
+
+func Test_userFromContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.Key, "user")
+
+	user, err := userFromContext(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", user)
+
+	user, err = userFromContext(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", user)
+
+	_, err = userFromContext(ctx, "other")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = userFromContext(context.Background(), "user")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
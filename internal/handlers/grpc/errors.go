@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/redirect"
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// Types of resources, that are reported in error details.
+const (
+	resourceShortURL = "short_url"
+	resourceUser     = "user"
+)
+
+// Types of precondition violations, that are reported in error details.
+const (
+	violationDeleted  = "DELETED"
+	violationDisabled = "DISABLED"
+)
+
+// statusError maps error of handlers to gRPC status with details,
+// resource is a name of short URL or user, that error is about.
+// Unknown errors are logged and reported as Internal without
+// message, so internals of storage aren't leaked.
+func statusError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var vErr *shortener.ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		br := &errdetails.BadRequest{}
+		for _, f := range vErr.Errors {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		return withDetails(codes.InvalidArgument, err.Error(), br)
+	case errors.Is(err, shortener.ErrorInvalidURL),
+		errors.Is(err, shortener.ErrorInvalidReason),
+		errors.Is(err, shortener.ErrorInvalidPageSize),
+		errors.Is(err, redirect.ErrorInvalidOptions):
+		return withDetails(codes.InvalidArgument, err.Error(), &errdetails.BadRequest{})
+	case errors.Is(err, handlers.ErrorUniqueURLViolation):
+		return withDetails(codes.AlreadyExists, err.Error(), &errdetails.ResourceInfo{
+			ResourceType: resourceShortURL,
+			ResourceName: resource,
+			Description:  "original URL is already shortened",
+		})
+	case errors.Is(err, handlers.ErrorURLIsGone):
+		return withDetails(codes.FailedPrecondition, err.Error(), precondition(violationDeleted, resource, "short URL is deleted"))
+	case errors.Is(err, handlers.ErrorURLIsDisabled):
+		return withDetails(codes.FailedPrecondition, err.Error(), precondition(violationDisabled, resource, "short URL is disabled"))
+	case errors.Is(err, storage.ErrorNoLinkFound), errors.Is(err, sql.ErrNoRows):
+		return withDetails(codes.NotFound, storage.ErrorNoLinkFound.Error(), &errdetails.ResourceInfo{
+			ResourceType: resourceShortURL,
+			ResourceName: resource,
+		})
+	case errors.Is(err, handlers.ErrorNoContent):
+		return withDetails(codes.NotFound, err.Error(), &errdetails.ResourceInfo{
+			ResourceType: resourceUser,
+			ResourceName: resource,
+			Description:  "user has no short URLs",
+		})
+	case errors.Is(err, storage.ErrorMethodIsNotImplemented):
+		return status.Error(codes.Unimplemented, err.Error())
+	default:
+		log.Printf("grpc: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// precondition returns details of failed precondition of subject.
+func precondition(violation string, subject string, description string) *errdetails.PreconditionFailure {
+	return &errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{
+			Type:        violation,
+			Subject:     subject,
+			Description: description,
+		}},
+	}
+}
+
+// withDetails returns status error with code, message and details.
+func withDetails(c codes.Code, msg string, details protoiface.MessageV1) error {
+	st, err := status.New(c, msg).WithDetails(details)
+	if err != nil {
+		return status.Error(c, msg)
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_statusError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+		details interface{}
+	}{
+		{
+			name: "Test case #1",
+			err: &shortener.ValidationError{Errors: []shortener.FieldError{
+				{Field: "url", Message: "is required"},
+			}},
+			code:    codes.InvalidArgument,
+			message: "invalid URL: url: is required",
+			details: &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "url", Description: "is required"}}},
+		},
+		{
+			name:    "Test case #2",
+			err:     handlers.ErrorUniqueURLViolation,
+			code:    codes.AlreadyExists,
+			message: "URL already exists",
+			details: &errdetails.ResourceInfo{ResourceType: "short_url", ResourceName: "asdf", Description: "original URL is already shortened"},
+		},
+		{
+			name:    "Test case #3",
+			err:     handlers.ErrorURLIsGone,
+			code:    codes.FailedPrecondition,
+			message: "URL is gone",
+			details: &errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{{Type: "DELETED", Subject: "asdf", Description: "short URL is deleted"}}},
+		},
+		{
+			name:    "Test case #4",
+			err:     fmt.Errorf("find: %w", storage.ErrorNoLinkFound),
+			code:    codes.NotFound,
+			message: "link not found",
+			details: &errdetails.ResourceInfo{ResourceType: "short_url", ResourceName: "asdf"},
+		},
+		{
+			name:    "Test case #5",
+			err:     handlers.ErrorNoContent,
+			code:    codes.NotFound,
+			message: "no content",
+			details: &errdetails.ResourceInfo{ResourceType: "user", ResourceName: "asdf", Description: "user has no short URLs"},
+		},
+		{
+			name:    "Test case #6",
+			err:     errors.New("pq: connection refused"),
+			code:    codes.Internal,
+			message: "internal error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(statusError(tt.err, "asdf"))
+			assert.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+
+			if tt.details == nil {
+				assert.Empty(t, st.Details())
+				return
+			}
+
+			if assert.Len(t, st.Details(), 1) {
+				assert.Equal(t, fmt.Sprint(tt.details), fmt.Sprint(st.Details()[0]))
+			}
+		})
+	}
+
+	assert.NoError(t, statusError(nil, "asdf"))
+}
@@ -2,16 +2,29 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"time"
 
 	"github.com/Fe4p3b/url-shortener/internal/handlers"
 	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 type ShortenerServer struct {
 	pb.UnimplementedShortenerServer
-	h handlers.Handlers
+	h      handlers.Handlers
+	policy *middleware.TrustedPolicy
 }
 
 func NewShortenerServer(h handlers.Handlers) *ShortenerServer {
@@ -20,15 +33,40 @@ func NewShortenerServer(h handlers.Handlers) *ShortenerServer {
 	}
 }
 
+// SetTrustedPolicy sets policy, that decides whether address of
+// visitor, that is passed in request, is trusted.
+func (s *ShortenerServer) SetTrustedPolicy(policy *middleware.TrustedPolicy) {
+	s.policy = policy
+}
+
 func (s *ShortenerServer) GetURL(ctx context.Context, in *pb.GetURLRequest) (*pb.GetURLResponse, error) {
 	var response pb.GetURLResponse
 
-	u, err := s.h.GetURL(in.ShortUrl)
+	q, err := url.ParseQuery(in.Query)
+	if err != nil {
+		return nil, withDetails(codes.InvalidArgument, err.Error(), &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "query", Description: err.Error()}},
+		})
+	}
+
+	v := &models.Visit{
+		Query:          q,
+		Referer:        in.Referer,
+		UserAgent:      in.UserAgent,
+		AcceptLanguage: in.AcceptLanguage,
+		IP:             clientIP(ctx, s.policy, in.Ip),
+		Variant:        in.Variant,
+		Time:           time.Now(),
+	}
+
+	u, err := s.h.GetURL(in.ShortUrl, v)
 	if err != nil {
-		response.Error = err.Error()
-		return &response, err
+		return nil, statusError(err, in.ShortUrl)
 	}
 	response.OriginalUrl = u.URL
+	response.Variant = v.Variant
+	response.Interstitial = len(u.Flags) > 0
+	response.Flags = u.Flags
 
 	return &response, nil
 }
@@ -36,33 +74,100 @@ func (s *ShortenerServer) GetURL(ctx context.Context, in *pb.GetURLRequest) (*pb
 func (s *ShortenerServer) PostURL(ctx context.Context, in *pb.PostURLRequest) (*pb.PostURLResponse, error) {
 	var response pb.PostURLResponse
 
-	u, err := s.h.PostURL(in.OriginalUrl, in.User)
+	user, err := userFromContext(ctx, in.User)
 	if err != nil {
-		response.Error = err.Error()
-		return &response, err
+		return nil, err
+	}
+
+	u, err := s.h.PostURL(&models.URL{
+		URL:    in.OriginalUrl,
+		UserID: user,
+		Options: models.Options{
+			Title:       in.Title,
+			QueryPolicy: models.QueryPolicy(in.QueryPolicy),
+			UTM:         in.Utm,
+			Targeting:   targetingFromProto(in.Targeting),
+			Geo:         geoFromProto(in.Geo),
+			Variants:    variantsFromProto(in.Variants),
+		},
+	})
+	if err != nil {
+		return nil, statusError(err, u)
 	}
 	response.ShortUrl = u
 	return &response, nil
 }
 
+func (s *ShortenerServer) ExpandURL(ctx context.Context, in *pb.ExpandURLRequest) (*pb.ExpandURLResponse, error) {
+	var response pb.ExpandURLResponse
+
+	e, err := s.h.ExpandURL(in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	response.ShortUrl = e.ShortURL
+	response.OriginalUrl = e.URL
+	response.Title = e.Title
+	response.State = string(e.State)
+	if e.CreatedAt != nil {
+		response.CreatedAt = e.CreatedAt.Format(time.RFC3339)
+	}
+
+	return &response, nil
+}
+
 func (s *ShortenerServer) GetUserURLs(ctx context.Context, in *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error) {
 	var response pb.GetUserURLsResponse
 
-	u, err := s.h.GetUserURLs(in.User)
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.h.GetUserURLs(user)
 	if err != nil {
-		return &response, err
+		return nil, statusError(err, user)
 	}
 
 	for _, v := range u {
-		response.Urls = append(response.Urls, &pb.URL{CorrelationId: v.CorrelationID, OriginalUrl: v.URL, ShortUrl: v.ShortURL, UserId: v.UserID, IsDeleted: v.IsDeleted})
+		response.Urls = append(response.Urls, urlToProto(v))
 	}
 	return &response, nil
 }
 
+// ListUserURLs sends URLs of user one by one, they are read from
+// storage by pages.
+func (s *ShortenerServer) ListUserURLs(in *pb.ListUserURLsRequest, stream pb.Shortener_ListUserURLsServer) error {
+	user, err := userFromContext(stream.Context(), "")
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+	err = s.h.ListUserURLs(user, func(v repositories.URL) error {
+		sendErr = stream.Send(urlToProto(v))
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return statusError(err, user)
+	}
+
+	return nil
+}
+
 func (s *ShortenerServer) DelUserURLs(ctx context.Context, in *pb.DelUserURLsRequest) (*pb.DelUserURLsResponse, error) {
 	var response pb.DelUserURLsResponse
 
-	s.h.DeleteUserURLs(in.User, in.Urls)
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	s.h.DeleteUserURLs(user, in.Urls)
 
 	return &response, nil
 }
@@ -70,14 +175,23 @@ func (s *ShortenerServer) DelUserURLs(ctx context.Context, in *pb.DelUserURLsReq
 func (s *ShortenerServer) ShortenBatch(ctx context.Context, in *pb.ShortenBatchRequest) (*pb.ShortenBatchResponse, error) {
 	var response pb.ShortenBatchResponse
 
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
 	batch := []repositories.URL{}
 	for _, v := range in.Urls {
-		batch = append(batch, repositories.URL{CorrelationID: v.CorrelationId, URL: v.OriginalUrl})
+		batch = append(batch, repositories.URL{
+			CorrelationID: v.CorrelationId,
+			URL:           v.OriginalUrl,
+			Options:       optionsFromProto(v),
+		})
 	}
 
-	URLs, err := s.h.ShortenBatch(in.User, &batch)
+	URLs, err := s.h.ShortenBatch(user, &batch)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err, "")
 	}
 
 	for _, v := range URLs {
@@ -87,12 +201,150 @@ func (s *ShortenerServer) ShortenBatch(ctx context.Context, in *pb.ShortenBatchR
 	return &response, nil
 }
 
+// ShortenStream stores URLs of stream one by one and sends result of
+// each URL, as soon as it is stored. Invalid and existing URLs are
+// reported by result, other errors stop the stream.
+func (s *ShortenerServer) ShortenStream(stream pb.Shortener_ShortenStreamServer) error {
+	user, err := userFromContext(stream.Context(), "")
+	if err != nil {
+		return err
+	}
+
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response := &pb.ShortenStreamResponse{CorrelationId: in.CorrelationId}
+		response.ShortUrl, err = s.h.PostURL(&models.URL{
+			URL:     in.OriginalUrl,
+			UserID:  user,
+			Options: optionsFromProto(in),
+		})
+		if err != nil {
+			st := status.Convert(statusError(err, in.CorrelationId))
+			if st.Code() == codes.Internal {
+				return st.Err()
+			}
+			response.Code = uint32(st.Code())
+			response.Message = st.Message()
+		}
+
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *ShortenerServer) GetTargeting(ctx context.Context, in *pb.GetTargetingRequest) (*pb.GetTargetingResponse, error) {
+	var response pb.GetTargetingResponse
+
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.h.GetTargeting(user, in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+	response.Rules = targetingToProto(rules)
+
+	return &response, nil
+}
+
+func (s *ShortenerServer) SetTargeting(ctx context.Context, in *pb.SetTargetingRequest) (*pb.SetTargetingResponse, error) {
+	var response pb.SetTargetingResponse
+
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.h.SetTargeting(user, in.ShortUrl, targetingFromProto(in.Rules)); err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	return &response, nil
+}
+
+func (s *ShortenerServer) GetGeo(ctx context.Context, in *pb.GetGeoRequest) (*pb.GetGeoResponse, error) {
+	var response pb.GetGeoResponse
+
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.h.GetGeo(user, in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+	response.Rules = geoToProto(rules)
+
+	return &response, nil
+}
+
+func (s *ShortenerServer) SetGeo(ctx context.Context, in *pb.SetGeoRequest) (*pb.SetGeoResponse, error) {
+	var response pb.SetGeoResponse
+
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.h.SetGeo(user, in.ShortUrl, geoFromProto(in.Rules)); err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	return &response, nil
+}
+
+func (s *ShortenerServer) GetVariants(ctx context.Context, in *pb.GetVariantsRequest) (*pb.GetVariantsResponse, error) {
+	var response pb.GetVariantsResponse
+
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := s.h.GetVariants(user, in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	for _, v := range variants {
+		response.Variants = append(response.Variants, &pb.Variant{Name: v.Name, Url: v.URL, Weight: uint32(v.Weight), Clicks: uint64(v.Clicks)})
+	}
+
+	return &response, nil
+}
+
+func (s *ShortenerServer) SetVariants(ctx context.Context, in *pb.SetVariantsRequest) (*pb.SetVariantsResponse, error) {
+	var response pb.SetVariantsResponse
+
+	user, err := userFromContext(ctx, in.User)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.h.SetVariants(user, in.ShortUrl, variantsFromProto(in.Variants)); err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	return &response, nil
+}
+
 func (s *ShortenerServer) Ping(ctx context.Context, in *empty.Empty) (*pb.PingResponse, error) {
 	var response pb.PingResponse
 
 	if err := s.h.Ping(); err != nil {
-		response.Error = err.Error()
-		return &response, err
+		log.Printf("grpc: ping: %v", err)
+		return nil, status.Error(codes.Unavailable, "storage is unavailable")
 	}
 
 	return &response, nil
@@ -103,10 +355,109 @@ func (s *ShortenerServer) GetStats(ctx context.Context, in *empty.Empty) (*pb.Ge
 
 	stats, err := s.h.GetStats()
 	if err != nil {
-		response.Error = err.Error()
-		return &response, err
+		return nil, statusError(err, "")
 	}
 	response.Stats = &pb.Stats{Urls: uint64(stats.URLs), Users: uint64(stats.Users)}
 
 	return &response, nil
 }
+
+// optionsFromProto returns options of URL message.
+func optionsFromProto(v *pb.URL) models.Options {
+	return models.Options{
+		Title:       v.Title,
+		QueryPolicy: models.QueryPolicy(v.QueryPolicy),
+		UTM:         v.Utm,
+		Targeting:   targetingFromProto(v.Targeting),
+		Geo:         geoFromProto(v.Geo),
+		Variants:    variantsFromProto(v.Variants),
+	}
+}
+
+// urlToProto returns URL message of URL with options.
+func urlToProto(v repositories.URL) *pb.URL {
+	return &pb.URL{
+		CorrelationId: v.CorrelationID,
+		OriginalUrl:   v.URL,
+		ShortUrl:      v.ShortURL,
+		UserId:        v.UserID,
+		IsDeleted:     v.IsDeleted,
+		Title:         v.Title,
+		QueryPolicy:   string(v.QueryPolicy),
+		Utm:           v.UTM,
+		Targeting:     targetingToProto(v.Targeting),
+		Geo:           geoToProto(v.Geo),
+		Variants:      variantsToProto(v.Variants),
+	}
+}
+
+func targetingFromProto(rules []*pb.TargetingRule) []models.TargetingRule {
+	var result []models.TargetingRule
+	for _, r := range rules {
+		result = append(result, models.TargetingRule{Platform: r.Platform, Language: r.Language, URL: r.Url})
+	}
+	return result
+}
+
+func targetingToProto(rules []models.TargetingRule) []*pb.TargetingRule {
+	var result []*pb.TargetingRule
+	for _, r := range rules {
+		result = append(result, &pb.TargetingRule{Platform: r.Platform, Language: r.Language, Url: r.URL})
+	}
+	return result
+}
+
+func geoFromProto(rules []*pb.GeoRule) []models.GeoRule {
+	var result []models.GeoRule
+	for _, r := range rules {
+		result = append(result, models.GeoRule{Countries: r.Countries, URL: r.Url})
+	}
+	return result
+}
+
+func geoToProto(rules []models.GeoRule) []*pb.GeoRule {
+	var result []*pb.GeoRule
+	for _, r := range rules {
+		result = append(result, &pb.GeoRule{Countries: r.Countries, Url: r.URL})
+	}
+	return result
+}
+
+func variantsFromProto(variants []*pb.Variant) []models.Variant {
+	var result []models.Variant
+	for _, v := range variants {
+		result = append(result, models.Variant{Name: v.Name, URL: v.Url, Weight: uint(v.Weight)})
+	}
+	return result
+}
+
+func variantsToProto(variants []models.Variant) []*pb.Variant {
+	var result []*pb.Variant
+	for _, v := range variants {
+		result = append(result, &pb.Variant{Name: v.Name, Url: v.URL, Weight: uint32(v.Weight)})
+	}
+	return result
+}
+
+// clientIP returns IP address of visitor by address of peer. Address,
+// that is passed in request or in x-real-ip metadata, is used only, if
+// peer is a trusted proxy of policy.
+func clientIP(ctx context.Context, policy *middleware.TrustedPolicy, ip string) string {
+	if policy == nil {
+		policy = &middleware.TrustedPolicy{}
+	}
+
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	if ip == "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get(RealIPKey); len(v) > 0 {
+			ip = v[0]
+		}
+	}
+
+	return policy.ClientIP(addr, ip)
+}
@@ -0,0 +1,222 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/Fe4p3b/url-shortener/internal/storage/memory"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestServer returns server with memory storage, where "user" is
+// a known user.
+func newTestServer(t *testing.T) *grpc.Server {
+	m := memory.NewMemory(map[string]string{})
+	h := handlers.NewHandler(shortener.NewShortener(m, "http://localhost:8080"))
+
+	a := NewAuthInterceptor(&fakeAuth{users: map[string]bool{"user": true}})
+	s := grpc.NewServer(grpc.UnaryInterceptor(a.Unary), grpc.StreamInterceptor(a.Stream))
+	pb.RegisterShortenerServer(s, NewShortenerServer(h))
+	pbv2.RegisterShortenerServer(s, NewShortenerV2Server(h))
+	t.Cleanup(s.Stop)
+
+	return s
+}
+
+// newTestClient starts server of newTestServer and returns client,
+// whose calls are authenticated as user.
+func newTestClient(t *testing.T) (pb.ShortenerClient, context.Context) {
+	conn, ctx := newTestConn(t)
+	return pb.NewShortenerClient(conn), ctx
+}
+
+// newTestConn starts server of newTestServer and returns connection
+// to it with context, whose calls are authenticated as user.
+func newTestConn(t *testing.T) (*grpc.ClientConn, context.Context) {
+	s := newTestServer(t)
+
+	listen := bufconn.Listen(1 << 20)
+	go s.Serve(listen)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listen.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, metadata.AppendToOutgoingContext(context.Background(), TokenKey, "user")
+}
+
+// conflictRepository is memory storage, where every original URL is
+// already shortened as "asdf".
+type conflictRepository struct {
+	*memory.Memory
+}
+
+func (r *conflictRepository) Save(u *models.URL) error {
+	u.ShortURL = "asdf"
+	return &pgconn.PgError{Code: pgerrcode.UniqueViolation}
+}
+
+func TestShortenerServer_PostURL_Conflict(t *testing.T) {
+	r := &conflictRepository{Memory: memory.NewMemory(map[string]string{})}
+	h := handlers.NewHandler(shortener.NewShortener(r, "http://localhost:8080"))
+	ctx := context.WithValue(context.Background(), middleware.Key, "user")
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "Test case #1",
+			call: func() error {
+				_, err := NewShortenerServer(h).PostURL(ctx, &pb.PostURLRequest{OriginalUrl: "https://yandex.ru"})
+				return err
+			},
+		},
+		{
+			name: "Test case #2",
+			call: func() error {
+				_, err := NewShortenerV2Server(h).CreateURL(ctx, &pbv2.CreateURLRequest{OriginalUrl: "https://yandex.ru"})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			assert.Equal(t, codes.AlreadyExists, st.Code())
+			require.Len(t, st.Details(), 1)
+
+			info, ok := st.Details()[0].(*errdetails.ResourceInfo)
+			require.True(t, ok)
+			assert.Equal(t, resourceShortURL, info.ResourceType)
+			assert.Equal(t, "http://localhost:8080/asdf", info.ResourceName)
+		})
+	}
+}
+
+func TestShortenerServer_ShortenStream(t *testing.T) {
+	c, ctx := newTestClient(t)
+
+	stream, err := c.ShortenStream(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		url  string
+		code codes.Code
+	}{
+		{name: "Test case #1", url: "https://yandex.ru", code: codes.OK},
+		{name: "Test case #2", url: "not a url", code: codes.InvalidArgument},
+		{name: "Test case #3", url: "https://practicum.yandex.ru", code: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, stream.Send(&pb.URL{CorrelationId: tt.name, OriginalUrl: tt.url}))
+
+			r, err := stream.Recv()
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, r.CorrelationId)
+			assert.Equal(t, uint32(tt.code), r.Code)
+			if tt.code == codes.OK {
+				assert.Contains(t, r.ShortUrl, "http://localhost:8080/")
+			} else {
+				assert.NotEmpty(t, r.Message)
+			}
+		})
+	}
+
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+
+	list, err := c.ListUserURLs(ctx, &pb.ListUserURLsRequest{})
+	require.NoError(t, err)
+
+	var URLs []string
+	for {
+		u, err := list.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		URLs = append(URLs, u.OriginalUrl)
+	}
+	assert.ElementsMatch(t, []string{"https://yandex.ru", "https://practicum.yandex.ru"}, URLs)
+}
+
+// visitRecorder keeps the last visit of GetURL.
+type visitRecorder struct {
+	handlers.Handlers
+	visit *models.Visit
+}
+
+func (r *visitRecorder) GetURL(shortURL string, v *models.Visit) (*repositories.URL, error) {
+	r.visit = v
+	return &repositories.URL{URL: "https://yandex.ru"}, nil
+}
+
+func TestShortenerServer_GetURL_ClientIP(t *testing.T) {
+	p, err := middleware.NewTrustedPolicy(nil, []string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		peer   string
+		ip     string
+		realIP string
+		want   string
+	}{
+		{name: "Test case #1", peer: "8.8.8.8", want: "8.8.8.8"},
+		{name: "Test case #2", peer: "8.8.8.8", ip: "95.59.0.1", want: "8.8.8.8"},
+		{name: "Test case #3", peer: "8.8.8.8", realIP: "95.59.0.1", want: "8.8.8.8"},
+		{name: "Test case #4", peer: "10.0.0.1", ip: "95.59.0.1", want: "95.59.0.1"},
+		{name: "Test case #5", peer: "10.0.0.1", realIP: "95.59.0.1", want: "95.59.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 1234}})
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RealIPKey, tt.realIP))
+			}
+
+			h := &visitRecorder{}
+			s := NewShortenerServer(h)
+			s.SetTrustedPolicy(p)
+			_, err := s.GetURL(ctx, &pb.GetURLRequest{ShortUrl: "asdf", Ip: tt.ip})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, h.visit.IP)
+
+			h = &visitRecorder{}
+			v2 := NewShortenerV2Server(h)
+			v2.SetTrustedPolicy(p)
+			_, err = v2.GetURL(ctx, &pbv2.GetURLRequest{ShortUrl: "asdf", Ip: tt.ip})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, h.visit.IP)
+		})
+	}
+}
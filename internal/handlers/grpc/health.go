@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultHealthInterval is an interval of checks of storage.
+const DefaultHealthInterval = 10 * time.Second
+
+// HealthChecker is a grpc.health.v1.Health service, whose status of
+// server and of Shortener services depends on availability of storage.
+type HealthChecker struct {
+	*health.Server
+
+	h        handlers.Handlers
+	interval time.Duration
+}
+
+func NewHealthChecker(h handlers.Handlers, interval time.Duration) *HealthChecker {
+	return &HealthChecker{
+		Server:   health.NewServer(),
+		h:        h,
+		interval: interval,
+	}
+}
+
+// Run updates status with interval until ctx is done, the first update
+// is made at once.
+func (c *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Update()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update pings storage and updates status, status isn't changed
+// after Shutdown.
+func (c *HealthChecker) Update() {
+	s := healthpb.HealthCheckResponse_SERVING
+	if err := c.h.Ping(); err != nil {
+		log.Printf("grpc: health: %v", err)
+		s = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	c.SetServingStatus("", s)
+	c.SetServingStatus(pb.Shortener_ServiceDesc.ServiceName, s)
+	c.SetServingStatus(pbv2.Shortener_ServiceDesc.ServiceName, s)
+}
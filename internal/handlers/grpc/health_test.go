@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// pingHandlers are handlers, whose Ping returns err.
+type pingHandlers struct {
+	handlers.Handlers
+	err error
+}
+
+func (h *pingHandlers) Ping() error {
+	return h.err
+}
+
+func TestHealthChecker_Update(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		shutdown bool
+		want     healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "Test case #1", want: healthpb.HealthCheckResponse_SERVING},
+		{name: "Test case #2", err: errors.New("connection refused"), want: healthpb.HealthCheckResponse_NOT_SERVING},
+		{name: "Test case #3", shutdown: true, want: healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewHealthChecker(&pingHandlers{err: tt.err}, DefaultHealthInterval)
+			c.Update()
+			if tt.shutdown {
+				c.Shutdown()
+				c.Update()
+			}
+
+			for _, service := range []string{"", pb.Shortener_ServiceDesc.ServiceName, pbv2.Shortener_ServiceDesc.ServiceName} {
+				r, err := c.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, r.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// HTTPServer serves gRPC requests of HTTP server by ServeHTTP of
+// server. Server can't drain requests, that it serves by ServeHTTP,
+// its GracefulStop panics, while any of them is active, so HTTPServer
+// tracks them and Stop waits for them before server is stopped.
+type HTTPServer struct {
+	s *grpc.Server
+
+	mu       sync.Mutex
+	active   int
+	idle     chan struct{}
+	stopping bool
+}
+
+// NewHTTPServer returns HTTPServer of server s.
+func NewHTTPServer(s *grpc.Server) *HTTPServer {
+	idle := make(chan struct{})
+	close(idle)
+
+	return &HTTPServer{s: s, idle: idle}
+}
+
+// ServeHTTP serves gRPC request by server.
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, s.s)
+}
+
+// serve serves gRPC request by h, that calls ServeHTTP of server.
+// Requests are rejected with Unavailable, once Stop is called.
+func (s *HTTPServer) serve(w http.ResponseWriter, r *http.Request, h http.Handler) {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		writeStatus(w, r, codes.Unavailable, "server is shutting down")
+		return
+	}
+	if s.active == 0 {
+		s.idle = make(chan struct{})
+	}
+	s.active++
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.active--
+		if s.active == 0 {
+			close(s.idle)
+		}
+		s.mu.Unlock()
+	}()
+
+	h.ServeHTTP(w, r)
+}
+
+// Stop rejects new gRPC requests of HTTP server and stops server
+// gracefully, if active requests are finished before ctx is done.
+// Otherwise server is stopped at once, so requests, that are still
+// active, like streams, are cancelled.
+func (s *HTTPServer) Stop(ctx context.Context) {
+	s.mu.Lock()
+	s.stopping = true
+	idle := s.idle
+	s.mu.Unlock()
+
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		s.s.Stop()
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.s.Stop()
+		<-stopped
+	}
+}
+
+// writeStatus responds to gRPC request with status code and message
+// without body.
+func writeStatus(w http.ResponseWriter, r *http.Request, code codes.Code, message string) {
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	w.Header().Set("Grpc-Message", message)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPServer_Stop(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+	}{
+		{name: "Test case #1"},
+		{name: "Test case #2", stream: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := grpc.NewServer()
+			c := NewHealthChecker(&pingHandlers{}, DefaultHealthInterval)
+			c.Update()
+			healthpb.RegisterHealthServer(s, c)
+
+			hs := NewHTTPServer(s)
+			srv := httptest.NewServer(NewMuxHandler(hs, http.NotFoundHandler(), false))
+			defer srv.Close()
+
+			conn, err := grpc.Dial(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err)
+			defer conn.Close()
+			client := healthpb.NewHealthClient(conn)
+
+			var watch healthpb.Health_WatchClient
+			if tt.stream {
+				watch, err = client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+				require.NoError(t, err)
+				r, err := watch.Recv()
+				require.NoError(t, err)
+				assert.Equal(t, healthpb.HealthCheckResponse_SERVING, r.Status)
+			} else {
+				_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+				require.NoError(t, err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			hs.Stop(ctx)
+			assert.Equal(t, tt.stream, ctx.Err() != nil)
+
+			if tt.stream {
+				_, err = watch.Recv()
+				assert.Error(t, err)
+			}
+
+			_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+		})
+	}
+}
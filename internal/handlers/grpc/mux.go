@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc/codes"
+)
+
+// NewMuxHandler returns handler, that serves gRPC requests by server
+// and other requests by handler, so both are served on the same port.
+// HTTP/2 without TLS (h2c) is accepted for plaintext gRPC. If
+// requireClientCert is set, gRPC requests without verified client
+// certificate are rejected, as HTTP clients may not have one.
+//
+// Server of NewMuxHandler isn't served on listener and connections
+// of h2c aren't closed by shutdown of HTTP server, so it should be
+// stopped by Stop of s after shutdown of HTTP server.
+func NewMuxHandler(s *HTTPServer, h http.Handler, requireClientCert bool) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isGRPC(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if requireClientCert && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			writeStatus(w, r, codes.Unauthenticated, "client certificate is required")
+			return
+		}
+
+		s.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
+
+// isGRPC reports whether request is a gRPC request.
+func isGRPC(r *http.Request) bool {
+	if r.ProtoMajor != 2 {
+		return false
+	}
+
+	ct := r.Header.Get("Content-Type")
+	return ct == "application/grpc" || strings.HasPrefix(ct, "application/grpc+")
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewMuxHandler(t *testing.T) {
+	tests := []struct {
+		name              string
+		requireClientCert bool
+		code              codes.Code
+	}{
+		{name: "Test case #1", code: codes.Unimplemented},
+		{name: "Test case #2", requireClientCert: true, code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := grpc.NewServer()
+			defer s.Stop()
+
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("http"))
+			})
+			srv := httptest.NewServer(NewMuxHandler(NewHTTPServer(s), h, tt.requireClientCert))
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, "http", string(body))
+
+			conn, err := grpc.Dial(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err)
+			defer conn.Close()
+
+			err = conn.Invoke(context.Background(), fullMethod("Ping"), &empty.Empty{}, &empty.Empty{})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -8,10 +8,10 @@ package proto
 
 import (
 	context "context"
-	empty "github.com/golang/protobuf/ptypes/empty"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -25,11 +25,23 @@ const _ = grpc.SupportPackageIsVersion7
 type ShortenerClient interface {
 	GetURL(ctx context.Context, in *GetURLRequest, opts ...grpc.CallOption) (*GetURLResponse, error)
 	PostURL(ctx context.Context, in *PostURLRequest, opts ...grpc.CallOption) (*PostURLResponse, error)
+	ExpandURL(ctx context.Context, in *ExpandURLRequest, opts ...grpc.CallOption) (*ExpandURLResponse, error)
 	GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error)
 	DelUserURLs(ctx context.Context, in *DelUserURLsRequest, opts ...grpc.CallOption) (*DelUserURLsResponse, error)
 	ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error)
-	Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PingResponse, error)
-	GetStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// ShortenStream stores URLs as they are received, results are sent
+	// back by correlation ID in the same order.
+	ShortenStream(ctx context.Context, opts ...grpc.CallOption) (Shortener_ShortenStreamClient, error)
+	// ListUserURLs streams URLs of user, that aren't deleted.
+	ListUserURLs(ctx context.Context, in *ListUserURLsRequest, opts ...grpc.CallOption) (Shortener_ListUserURLsClient, error)
+	GetTargeting(ctx context.Context, in *GetTargetingRequest, opts ...grpc.CallOption) (*GetTargetingResponse, error)
+	SetTargeting(ctx context.Context, in *SetTargetingRequest, opts ...grpc.CallOption) (*SetTargetingResponse, error)
+	GetGeo(ctx context.Context, in *GetGeoRequest, opts ...grpc.CallOption) (*GetGeoResponse, error)
+	SetGeo(ctx context.Context, in *SetGeoRequest, opts ...grpc.CallOption) (*SetGeoResponse, error)
+	GetVariants(ctx context.Context, in *GetVariantsRequest, opts ...grpc.CallOption) (*GetVariantsResponse, error)
+	SetVariants(ctx context.Context, in *SetVariantsRequest, opts ...grpc.CallOption) (*SetVariantsResponse, error)
+	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PingResponse, error)
+	GetStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetStatsResponse, error)
 }
 
 type shortenerClient struct {
@@ -58,6 +70,15 @@ func (c *shortenerClient) PostURL(ctx context.Context, in *PostURLRequest, opts
 	return out, nil
 }
 
+func (c *shortenerClient) ExpandURL(ctx context.Context, in *ExpandURLRequest, opts ...grpc.CallOption) (*ExpandURLResponse, error) {
+	out := new(ExpandURLResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/ExpandURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *shortenerClient) GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error) {
 	out := new(GetUserURLsResponse)
 	err := c.cc.Invoke(ctx, "/grpc.Shortener/GetUserURLs", in, out, opts...)
@@ -85,7 +106,124 @@ func (c *shortenerClient) ShortenBatch(ctx context.Context, in *ShortenBatchRequ
 	return out, nil
 }
 
-func (c *shortenerClient) Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PingResponse, error) {
+func (c *shortenerClient) ShortenStream(ctx context.Context, opts ...grpc.CallOption) (Shortener_ShortenStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[0], "/grpc.Shortener/ShortenStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerShortenStreamClient{stream}
+	return x, nil
+}
+
+type Shortener_ShortenStreamClient interface {
+	Send(*URL) error
+	Recv() (*ShortenStreamResponse, error)
+	grpc.ClientStream
+}
+
+type shortenerShortenStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerShortenStreamClient) Send(m *URL) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *shortenerShortenStreamClient) Recv() (*ShortenStreamResponse, error) {
+	m := new(ShortenStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shortenerClient) ListUserURLs(ctx context.Context, in *ListUserURLsRequest, opts ...grpc.CallOption) (Shortener_ListUserURLsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[1], "/grpc.Shortener/ListUserURLs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerListUserURLsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Shortener_ListUserURLsClient interface {
+	Recv() (*URL, error)
+	grpc.ClientStream
+}
+
+type shortenerListUserURLsClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerListUserURLsClient) Recv() (*URL, error) {
+	m := new(URL)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shortenerClient) GetTargeting(ctx context.Context, in *GetTargetingRequest, opts ...grpc.CallOption) (*GetTargetingResponse, error) {
+	out := new(GetTargetingResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/GetTargeting", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) SetTargeting(ctx context.Context, in *SetTargetingRequest, opts ...grpc.CallOption) (*SetTargetingResponse, error) {
+	out := new(SetTargetingResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/SetTargeting", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) GetGeo(ctx context.Context, in *GetGeoRequest, opts ...grpc.CallOption) (*GetGeoResponse, error) {
+	out := new(GetGeoResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/GetGeo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) SetGeo(ctx context.Context, in *SetGeoRequest, opts ...grpc.CallOption) (*SetGeoResponse, error) {
+	out := new(SetGeoResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/SetGeo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) GetVariants(ctx context.Context, in *GetVariantsRequest, opts ...grpc.CallOption) (*GetVariantsResponse, error) {
+	out := new(GetVariantsResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/GetVariants", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) SetVariants(ctx context.Context, in *SetVariantsRequest, opts ...grpc.CallOption) (*SetVariantsResponse, error) {
+	out := new(SetVariantsResponse)
+	err := c.cc.Invoke(ctx, "/grpc.Shortener/SetVariants", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PingResponse, error) {
 	out := new(PingResponse)
 	err := c.cc.Invoke(ctx, "/grpc.Shortener/Ping", in, out, opts...)
 	if err != nil {
@@ -94,7 +232,7 @@ func (c *shortenerClient) Ping(ctx context.Context, in *empty.Empty, opts ...grp
 	return out, nil
 }
 
-func (c *shortenerClient) GetStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+func (c *shortenerClient) GetStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetStatsResponse, error) {
 	out := new(GetStatsResponse)
 	err := c.cc.Invoke(ctx, "/grpc.Shortener/GetStats", in, out, opts...)
 	if err != nil {
@@ -109,11 +247,23 @@ func (c *shortenerClient) GetStats(ctx context.Context, in *empty.Empty, opts ..
 type ShortenerServer interface {
 	GetURL(context.Context, *GetURLRequest) (*GetURLResponse, error)
 	PostURL(context.Context, *PostURLRequest) (*PostURLResponse, error)
+	ExpandURL(context.Context, *ExpandURLRequest) (*ExpandURLResponse, error)
 	GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error)
 	DelUserURLs(context.Context, *DelUserURLsRequest) (*DelUserURLsResponse, error)
 	ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error)
-	Ping(context.Context, *empty.Empty) (*PingResponse, error)
-	GetStats(context.Context, *empty.Empty) (*GetStatsResponse, error)
+	// ShortenStream stores URLs as they are received, results are sent
+	// back by correlation ID in the same order.
+	ShortenStream(Shortener_ShortenStreamServer) error
+	// ListUserURLs streams URLs of user, that aren't deleted.
+	ListUserURLs(*ListUserURLsRequest, Shortener_ListUserURLsServer) error
+	GetTargeting(context.Context, *GetTargetingRequest) (*GetTargetingResponse, error)
+	SetTargeting(context.Context, *SetTargetingRequest) (*SetTargetingResponse, error)
+	GetGeo(context.Context, *GetGeoRequest) (*GetGeoResponse, error)
+	SetGeo(context.Context, *SetGeoRequest) (*SetGeoResponse, error)
+	GetVariants(context.Context, *GetVariantsRequest) (*GetVariantsResponse, error)
+	SetVariants(context.Context, *SetVariantsRequest) (*SetVariantsResponse, error)
+	Ping(context.Context, *emptypb.Empty) (*PingResponse, error)
+	GetStats(context.Context, *emptypb.Empty) (*GetStatsResponse, error)
 	mustEmbedUnimplementedShortenerServer()
 }
 
@@ -127,6 +277,9 @@ func (UnimplementedShortenerServer) GetURL(context.Context, *GetURLRequest) (*Ge
 func (UnimplementedShortenerServer) PostURL(context.Context, *PostURLRequest) (*PostURLResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PostURL not implemented")
 }
+func (UnimplementedShortenerServer) ExpandURL(context.Context, *ExpandURLRequest) (*ExpandURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExpandURL not implemented")
+}
 func (UnimplementedShortenerServer) GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserURLs not implemented")
 }
@@ -136,10 +289,34 @@ func (UnimplementedShortenerServer) DelUserURLs(context.Context, *DelUserURLsReq
 func (UnimplementedShortenerServer) ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ShortenBatch not implemented")
 }
-func (UnimplementedShortenerServer) Ping(context.Context, *empty.Empty) (*PingResponse, error) {
+func (UnimplementedShortenerServer) ShortenStream(Shortener_ShortenStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ShortenStream not implemented")
+}
+func (UnimplementedShortenerServer) ListUserURLs(*ListUserURLsRequest, Shortener_ListUserURLsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListUserURLs not implemented")
+}
+func (UnimplementedShortenerServer) GetTargeting(context.Context, *GetTargetingRequest) (*GetTargetingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTargeting not implemented")
+}
+func (UnimplementedShortenerServer) SetTargeting(context.Context, *SetTargetingRequest) (*SetTargetingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTargeting not implemented")
+}
+func (UnimplementedShortenerServer) GetGeo(context.Context, *GetGeoRequest) (*GetGeoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGeo not implemented")
+}
+func (UnimplementedShortenerServer) SetGeo(context.Context, *SetGeoRequest) (*SetGeoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetGeo not implemented")
+}
+func (UnimplementedShortenerServer) GetVariants(context.Context, *GetVariantsRequest) (*GetVariantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVariants not implemented")
+}
+func (UnimplementedShortenerServer) SetVariants(context.Context, *SetVariantsRequest) (*SetVariantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetVariants not implemented")
+}
+func (UnimplementedShortenerServer) Ping(context.Context, *emptypb.Empty) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
-func (UnimplementedShortenerServer) GetStats(context.Context, *empty.Empty) (*GetStatsResponse, error) {
+func (UnimplementedShortenerServer) GetStats(context.Context, *emptypb.Empty) (*GetStatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
 func (UnimplementedShortenerServer) mustEmbedUnimplementedShortenerServer() {}
@@ -191,6 +368,24 @@ func _Shortener_PostURL_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_ExpandURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpandURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).ExpandURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/ExpandURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).ExpandURL(ctx, req.(*ExpandURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shortener_GetUserURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetUserURLsRequest)
 	if err := dec(in); err != nil {
@@ -245,8 +440,163 @@ func _Shortener_ShortenBatch_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_ShortenStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ShortenerServer).ShortenStream(&shortenerShortenStreamServer{stream})
+}
+
+type Shortener_ShortenStreamServer interface {
+	Send(*ShortenStreamResponse) error
+	Recv() (*URL, error)
+	grpc.ServerStream
+}
+
+type shortenerShortenStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerShortenStreamServer) Send(m *ShortenStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *shortenerShortenStreamServer) Recv() (*URL, error) {
+	m := new(URL)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Shortener_ListUserURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListUserURLsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShortenerServer).ListUserURLs(m, &shortenerListUserURLsServer{stream})
+}
+
+type Shortener_ListUserURLsServer interface {
+	Send(*URL) error
+	grpc.ServerStream
+}
+
+type shortenerListUserURLsServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerListUserURLsServer) Send(m *URL) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Shortener_GetTargeting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTargetingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetTargeting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/GetTargeting",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetTargeting(ctx, req.(*GetTargetingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_SetTargeting_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTargetingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).SetTargeting(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/SetTargeting",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).SetTargeting(ctx, req.(*SetTargetingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_GetGeo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGeoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetGeo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/GetGeo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetGeo(ctx, req.(*GetGeoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_SetGeo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetGeoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).SetGeo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/SetGeo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).SetGeo(ctx, req.(*SetGeoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_GetVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVariantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/GetVariants",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetVariants(ctx, req.(*GetVariantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_SetVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetVariantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).SetVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Shortener/SetVariants",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).SetVariants(ctx, req.(*SetVariantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shortener_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -258,13 +608,13 @@ func _Shortener_Ping_Handler(srv interface{}, ctx context.Context, dec func(inte
 		FullMethod: "/grpc.Shortener/Ping",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ShortenerServer).Ping(ctx, req.(*empty.Empty))
+		return srv.(ShortenerServer).Ping(ctx, req.(*emptypb.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
 func _Shortener_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -276,7 +626,7 @@ func _Shortener_GetStats_Handler(srv interface{}, ctx context.Context, dec func(
 		FullMethod: "/grpc.Shortener/GetStats",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ShortenerServer).GetStats(ctx, req.(*empty.Empty))
+		return srv.(ShortenerServer).GetStats(ctx, req.(*emptypb.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -296,6 +646,10 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PostURL",
 			Handler:    _Shortener_PostURL_Handler,
 		},
+		{
+			MethodName: "ExpandURL",
+			Handler:    _Shortener_ExpandURL_Handler,
+		},
 		{
 			MethodName: "GetUserURLs",
 			Handler:    _Shortener_GetUserURLs_Handler,
@@ -308,6 +662,30 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ShortenBatch",
 			Handler:    _Shortener_ShortenBatch_Handler,
 		},
+		{
+			MethodName: "GetTargeting",
+			Handler:    _Shortener_GetTargeting_Handler,
+		},
+		{
+			MethodName: "SetTargeting",
+			Handler:    _Shortener_SetTargeting_Handler,
+		},
+		{
+			MethodName: "GetGeo",
+			Handler:    _Shortener_GetGeo_Handler,
+		},
+		{
+			MethodName: "SetGeo",
+			Handler:    _Shortener_SetGeo_Handler,
+		},
+		{
+			MethodName: "GetVariants",
+			Handler:    _Shortener_GetVariants_Handler,
+		},
+		{
+			MethodName: "SetVariants",
+			Handler:    _Shortener_SetVariants_Handler,
+		},
 		{
 			MethodName: "Ping",
 			Handler:    _Shortener_Ping_Handler,
@@ -317,6 +695,18 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Shortener_GetStats_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ShortenStream",
+			Handler:       _Shortener_ShortenStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListUserURLs",
+			Handler:       _Shortener_ListUserURLs_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "proto/grpc.proto",
 }
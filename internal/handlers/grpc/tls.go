@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+)
+
+var ErrorInvalidCABundle = errors.New("no certificates in CA bundle")
+
+// ServerCredentials returns TLS credentials of server by certificate
+// and key files. If caFile is set, clients should present certificate,
+// that is signed by one of CAs of the bundle.
+func ServerCredentials(certFile string, keyFile string, caFile string) (credentials.TransportCredentials, error) {
+	cfg, err := ServerTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// ServerTLSConfig returns TLS config of ServerCredentials.
+func ServerTLSConfig(certFile string, keyFile string, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		pool, err := loadCABundle(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// ClientCredentials returns TLS credentials of client. Server is
+// verified by CAs of caFile, or by system CAs, if it is empty. If
+// certFile and keyFile are set, client presents its certificate.
+func ClientCredentials(caFile string, certFile string, keyFile string) (credentials.TransportCredentials, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		pool, err := loadCABundle(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// loadCABundle reads PEM encoded certificates of CAs.
+func loadCABundle(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidCABundle, caFile)
+	}
+
+	return pool, nil
+}
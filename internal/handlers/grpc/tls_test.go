@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testCert writes certificate and key signed by parent, or self-signed
+// certificate, if parent is nil, to dir.
+func testCert(t *testing.T, dir string, name string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	issuer, signer := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestServerCredentials(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCert(t, dir, "ca", nil)
+	testCert(t, dir, "server", &ca)
+	testCert(t, dir, "client", &ca)
+	other := testCert(t, dir, "other", nil)
+	testCert(t, dir, "stranger", &other)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.crt"), []byte("no certificates"), 0600))
+
+	path := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		name      string
+		clientCA  string
+		client    string
+		serverErr bool
+		code      codes.Code
+	}{
+		{name: "Test case #1", code: codes.Unimplemented},
+		{name: "Test case #2", clientCA: "ca.crt", client: "client", code: codes.Unimplemented},
+		{name: "Test case #3", clientCA: "ca.crt", code: codes.Unavailable},
+		{name: "Test case #4", clientCA: "ca.crt", client: "stranger", code: codes.Unavailable},
+		{name: "Test case #5", clientCA: "empty.crt", serverErr: true},
+		{name: "Test case #6", clientCA: "missing.crt", serverErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := ServerCredentials(path("server.crt"), path("server.key"), path(tt.clientCA))
+			if tt.serverErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			listen, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			s := grpc.NewServer(grpc.Creds(creds))
+			go s.Serve(listen)
+			defer s.Stop()
+
+			var certFile, keyFile string
+			if tt.client != "" {
+				certFile, keyFile = path(tt.client+".crt"), path(tt.client+".key")
+			}
+			clientCreds, err := ClientCredentials(path("ca.crt"), certFile, keyFile)
+			require.NoError(t, err)
+
+			conn, err := grpc.Dial(listen.Addr().String(), grpc.WithTransportCredentials(clientCreds))
+			require.NoError(t, err)
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err = conn.Invoke(ctx, fullMethod("Ping"), &empty.Empty{}, &empty.Empty{})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
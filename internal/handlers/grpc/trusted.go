@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RealIPKey is a key of metadata, where proxy passes address of client.
+const RealIPKey = "x-real-ip"
+
+// DefaultInternalMethods are methods, that are allowed only for
+// trusted networks by default.
+var DefaultInternalMethods = []string{fullMethod("GetStats"), fullMethodV2("GetStats")}
+
+// TrustedInterceptor forbids calls of internal methods by clients, that
+// don't belong to trusted networks, as TrustedNetworksOnlyMiddleware
+// does for HTTP.
+type TrustedInterceptor struct {
+	policy *middleware.TrustedPolicy
+
+	// methods are full names of internal methods, like
+	// "/grpc.Shortener/GetStats".
+	methods map[string]struct{}
+}
+
+func NewTrustedInterceptor(policy *middleware.TrustedPolicy, methods []string) *TrustedInterceptor {
+	m := make(map[string]struct{}, len(methods))
+	for _, v := range methods {
+		m[v] = struct{}{}
+	}
+
+	return &TrustedInterceptor{policy: policy, methods: m}
+}
+
+// Unary is an interceptor of unary calls.
+func (t *TrustedInterceptor) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := t.check(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// Stream is an interceptor of streaming calls.
+func (t *TrustedInterceptor) Stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := t.check(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// check returns PermissionDenied, if method is internal and client
+// doesn't belong to trusted networks.
+func (t *TrustedInterceptor) check(ctx context.Context, method string) error {
+	if _, ok := t.methods[method]; !ok {
+		return nil
+	}
+
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	var realIP string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(RealIPKey); len(v) > 0 {
+		realIP = v[0]
+	}
+
+	if !t.policy.Allowed(t.policy.ClientIP(addr, realIP)) {
+		return status.Error(codes.PermissionDenied, "method is allowed only for trusted networks")
+	}
+
+	return nil
+}
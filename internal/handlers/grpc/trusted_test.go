@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestTrustedInterceptor_Unary(t *testing.T) {
+	p, err := middleware.NewTrustedPolicy([]string{"192.168.1.0/24"}, []string{"10.0.0.1"})
+	assert.NoError(t, err)
+
+	i := NewTrustedInterceptor(p, DefaultInternalMethods)
+
+	tests := []struct {
+		name   string
+		method string
+		peer   string
+		realIP string
+		code   codes.Code
+	}{
+		{name: "Test case #1", method: "GetStats", peer: "192.168.1.5", code: codes.OK},
+		{name: "Test case #2", method: "GetStats", peer: "8.8.8.8", code: codes.PermissionDenied},
+		{name: "Test case #3", method: "GetStats", peer: "10.0.0.1", realIP: "192.168.1.7", code: codes.OK},
+		{name: "Test case #4", method: "GetStats", peer: "8.8.8.8", realIP: "192.168.1.7", code: codes.PermissionDenied},
+		{name: "Test case #5", method: "GetURL", peer: "8.8.8.8", code: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 1234}})
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RealIPKey, tt.realIP))
+			}
+
+			_, err := i.Unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod(tt.method)}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
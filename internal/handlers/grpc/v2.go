@@ -0,0 +1,371 @@
+package grpc
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// DefaultPageSize is a number of links of page of ListURLs, if
+	// page size isn't set.
+	DefaultPageSize = 50
+
+	// MaxPageSize is a maximum number of links of page of ListURLs.
+	MaxPageSize = 1000
+)
+
+// queryPolicies maps query policies of v2 API to query policies.
+var queryPolicies = map[pbv2.QueryPolicy]models.QueryPolicy{
+	pbv2.QueryPolicy_QUERY_POLICY_UNSPECIFIED: "",
+	pbv2.QueryPolicy_QUERY_POLICY_DROP:        models.QueryDrop,
+	pbv2.QueryPolicy_QUERY_POLICY_KEEP:        models.QueryKeep,
+	pbv2.QueryPolicy_QUERY_POLICY_OVERRIDE:    models.QueryOverride,
+	pbv2.QueryPolicy_QUERY_POLICY_APPEND:      models.QueryAppend,
+}
+
+// linkStates maps states of short URLs to states of v2 API.
+var linkStates = map[models.LinkState]pbv2.LinkState{
+	models.LinkActive:   pbv2.LinkState_LINK_STATE_ACTIVE,
+	models.LinkDeleted:  pbv2.LinkState_LINK_STATE_DELETED,
+	models.LinkDisabled: pbv2.LinkState_LINK_STATE_DISABLED,
+}
+
+// optionsUpdates set fields of options by path of field mask.
+var optionsUpdates = map[string]func(dst *models.Options, src models.Options){
+	"title":        func(dst *models.Options, src models.Options) { dst.Title = src.Title },
+	"query_policy": func(dst *models.Options, src models.Options) { dst.QueryPolicy = src.QueryPolicy },
+	"utm":          func(dst *models.Options, src models.Options) { dst.UTM = src.UTM },
+	"targeting":    func(dst *models.Options, src models.Options) { dst.Targeting = src.Targeting },
+	"geo":          func(dst *models.Options, src models.Options) { dst.Geo = src.Geo },
+	"variants":     func(dst *models.Options, src models.Options) { dst.Variants = src.Variants },
+}
+
+// ShortenerV2Server implements shortener.v2.Shortener service, it
+// runs alongside ShortenerServer with the same handlers.
+type ShortenerV2Server struct {
+	pbv2.UnimplementedShortenerServer
+	h      handlers.Handlers
+	policy *middleware.TrustedPolicy
+}
+
+func NewShortenerV2Server(h handlers.Handlers) *ShortenerV2Server {
+	return &ShortenerV2Server{
+		h: h,
+	}
+}
+
+// SetTrustedPolicy sets policy, that decides whether address of
+// visitor, that is passed in request, is trusted.
+func (s *ShortenerV2Server) SetTrustedPolicy(policy *middleware.TrustedPolicy) {
+	s.policy = policy
+}
+
+func (s *ShortenerV2Server) GetURL(ctx context.Context, in *pbv2.GetURLRequest) (*pbv2.GetURLResponse, error) {
+	q, err := url.ParseQuery(in.Query)
+	if err != nil {
+		return nil, withDetails(codes.InvalidArgument, err.Error(), &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "query", Description: err.Error()}},
+		})
+	}
+
+	v := &models.Visit{
+		Query:          q,
+		Referer:        in.Referer,
+		UserAgent:      in.UserAgent,
+		AcceptLanguage: in.AcceptLanguage,
+		IP:             clientIP(ctx, s.policy, in.Ip),
+		Variant:        in.Variant,
+		Time:           time.Now(),
+	}
+
+	u, err := s.h.GetURL(in.ShortUrl, v)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	return &pbv2.GetURLResponse{
+		OriginalUrl:  u.URL,
+		Variant:      v.Variant,
+		Interstitial: len(u.Flags) > 0,
+		Flags:        u.Flags,
+	}, nil
+}
+
+func (s *ShortenerV2Server) ExpandURL(ctx context.Context, in *pbv2.ExpandURLRequest) (*pbv2.ExpandURLResponse, error) {
+	e, err := s.h.ExpandURL(in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	response := &pbv2.ExpandURLResponse{
+		ShortUrl:    e.ShortURL,
+		OriginalUrl: e.URL,
+		Title:       e.Title,
+		State:       linkStates[e.State],
+		Reason:      e.Reason,
+		Flags:       e.Flags,
+	}
+	if e.CreatedAt != nil {
+		response.CreateTime = timestamppb.New(*e.CreatedAt)
+	}
+
+	return response, nil
+}
+
+func (s *ShortenerV2Server) CreateURL(ctx context.Context, in *pbv2.CreateURLRequest) (*pbv2.CreateURLResponse, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.h.PostURL(&models.URL{
+		URL:     in.OriginalUrl,
+		UserID:  user,
+		Options: optionsFromV2(in.Options),
+	})
+	if err != nil {
+		return nil, statusError(err, u)
+	}
+
+	return &pbv2.CreateURLResponse{ShortUrl: u}, nil
+}
+
+func (s *ShortenerV2Server) BatchCreateURLs(ctx context.Context, in *pbv2.BatchCreateURLsRequest) (*pbv2.BatchCreateURLsResponse, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make([]repositories.URL, 0, len(in.Entries))
+	for _, v := range in.Entries {
+		batch = append(batch, repositories.URL{
+			CorrelationID: v.CorrelationId,
+			URL:           v.OriginalUrl,
+			Options:       optionsFromV2(v.Options),
+		})
+	}
+
+	URLs, err := s.h.ShortenBatch(user, &batch)
+	if err != nil {
+		return nil, statusError(err, "")
+	}
+
+	response := &pbv2.BatchCreateURLsResponse{}
+	for _, v := range URLs {
+		response.Results = append(response.Results, &pbv2.BatchCreateURLsResponse_Result{CorrelationId: v.CorrelationID, ShortUrl: v.ShortURL})
+	}
+
+	return response, nil
+}
+
+// ListURLs returns page of links of user. Page token is an encoded
+// short URL, that page goes after.
+func (s *ShortenerV2Server) ListURLs(ctx context.Context, in *pbv2.ListURLsRequest) (*pbv2.ListURLsResponse, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(in.PageSize)
+	switch {
+	case size < 0:
+		return nil, withDetails(codes.InvalidArgument, "negative page size", &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "page_size", Description: "page size should not be negative"}},
+		})
+	case size == 0:
+		size = DefaultPageSize
+	case size > MaxPageSize:
+		size = MaxPageSize
+	}
+
+	after, err := handlers.DecodePageToken(in.PageToken)
+	if err != nil {
+		return nil, withDetails(codes.InvalidArgument, "invalid page token", &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "page_token", Description: err.Error()}},
+		})
+	}
+
+	URLs, next, err := s.h.GetUserURLsPage(user, after, size)
+	if err != nil {
+		return nil, statusError(err, user)
+	}
+
+	response := &pbv2.ListURLsResponse{}
+	for _, v := range URLs {
+		response.Links = append(response.Links, linkToV2(v))
+	}
+	if next != "" {
+		response.NextPageToken = handlers.EncodePageToken(next)
+	}
+
+	return response, nil
+}
+
+func (s *ShortenerV2Server) DeleteURLs(ctx context.Context, in *pbv2.DeleteURLsRequest) (*empty.Empty, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.h.DeleteUserURLs(user, in.ShortUrls)
+
+	return &empty.Empty{}, nil
+}
+
+func (s *ShortenerV2Server) GetURLOptions(ctx context.Context, in *pbv2.GetURLOptionsRequest) (*pbv2.LinkOptions, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := s.h.GetOptions(user, in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	return optionsToV2(*o), nil
+}
+
+// UpdateURLOptions replaces fields of options of short URL, that are
+// listed by update mask, or all fields, if mask is empty.
+func (s *ShortenerV2Server) UpdateURLOptions(ctx context.Context, in *pbv2.UpdateURLOptionsRequest) (*pbv2.LinkOptions, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := in.UpdateMask.GetPaths()
+	if len(paths) == 0 {
+		for path := range optionsUpdates {
+			paths = append(paths, path)
+		}
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, path := range paths {
+		if _, ok := optionsUpdates[path]; !ok {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "update_mask", Description: "unknown field " + path})
+		}
+	}
+	if len(violations) > 0 {
+		return nil, withDetails(codes.InvalidArgument, "invalid update mask", &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	src := optionsFromV2(in.Options)
+	o, err := s.h.UpdateOptions(user, in.ShortUrl, func(o *models.Options) {
+		for _, path := range paths {
+			optionsUpdates[path](o, src)
+		}
+	})
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	return optionsToV2(*o), nil
+}
+
+func (s *ShortenerV2Server) GetVariantStats(ctx context.Context, in *pbv2.GetVariantStatsRequest) (*pbv2.GetVariantStatsResponse, error) {
+	user, err := userFromContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.h.GetVariants(user, in.ShortUrl)
+	if err != nil {
+		return nil, statusError(err, in.ShortUrl)
+	}
+
+	response := &pbv2.GetVariantStatsResponse{}
+	for _, v := range stats {
+		response.Stats = append(response.Stats, &pbv2.GetVariantStatsResponse_Stats{
+			Variant: &pbv2.Variant{Name: v.Name, Url: v.URL, Weight: uint32(v.Weight)},
+			Clicks:  uint64(v.Clicks),
+		})
+	}
+
+	return response, nil
+}
+
+func (s *ShortenerV2Server) GetStats(ctx context.Context, in *empty.Empty) (*pbv2.GetStatsResponse, error) {
+	stats, err := s.h.GetStats()
+	if err != nil {
+		return nil, statusError(err, "")
+	}
+
+	return &pbv2.GetStatsResponse{Urls: uint64(stats.URLs), Users: uint64(stats.Users)}, nil
+}
+
+// optionsFromV2 returns options of v2 API message, nil message is
+// empty options.
+func optionsFromV2(o *pbv2.LinkOptions) models.Options {
+	if o == nil {
+		return models.Options{}
+	}
+
+	result := models.Options{
+		Title:       o.Title,
+		QueryPolicy: queryPolicies[o.QueryPolicy],
+		UTM:         o.Utm,
+	}
+	for _, r := range o.Targeting {
+		result.Targeting = append(result.Targeting, models.TargetingRule{Platform: r.Platform, Language: r.Language, URL: r.Url})
+	}
+	for _, r := range o.Geo {
+		result.Geo = append(result.Geo, models.GeoRule{Countries: r.Countries, URL: r.Url})
+	}
+	for _, v := range o.Variants {
+		result.Variants = append(result.Variants, models.Variant{Name: v.Name, URL: v.Url, Weight: uint(v.Weight)})
+	}
+
+	return result
+}
+
+// optionsToV2 returns v2 API message of options.
+func optionsToV2(o models.Options) *pbv2.LinkOptions {
+	result := &pbv2.LinkOptions{
+		Title: o.Title,
+		Utm:   o.UTM,
+	}
+	for p, qp := range queryPolicies {
+		if qp == o.QueryPolicy {
+			result.QueryPolicy = p
+		}
+	}
+	for _, r := range o.Targeting {
+		result.Targeting = append(result.Targeting, &pbv2.TargetingRule{Platform: r.Platform, Language: r.Language, Url: r.URL})
+	}
+	for _, r := range o.Geo {
+		result.Geo = append(result.Geo, &pbv2.GeoRule{Countries: r.Countries, Url: r.URL})
+	}
+	for _, v := range o.Variants {
+		result.Variants = append(result.Variants, &pbv2.Variant{Name: v.Name, Url: v.URL, Weight: uint32(v.Weight)})
+	}
+
+	return result
+}
+
+// linkToV2 returns v2 API message of URL of user.
+func linkToV2(u repositories.URL) *pbv2.Link {
+	l := &pbv2.Link{
+		ShortUrl:    u.ShortURL,
+		OriginalUrl: u.URL,
+		Options:     optionsToV2(u.Options),
+		Flags:       u.Flags,
+	}
+	if !u.CreatedAt.IsZero() {
+		l.CreateTime = timestamppb.New(u.CreatedAt)
+	}
+
+	return l
+}
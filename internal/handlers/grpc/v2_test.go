@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"testing"
+
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestShortenerV2Server(t *testing.T) {
+	conn, ctx := newTestConn(t)
+	c := pbv2.NewShortenerClient(conn)
+
+	var shortURLs []string
+	for _, u := range []string{"https://yandex.ru", "https://practicum.yandex.ru", "https://market.yandex.ru"} {
+		r, err := c.CreateURL(ctx, &pbv2.CreateURLRequest{
+			OriginalUrl: u,
+			Options:     &pbv2.LinkOptions{Title: "Yandex", QueryPolicy: pbv2.QueryPolicy_QUERY_POLICY_KEEP},
+		})
+		require.NoError(t, err)
+		shortURLs = append(shortURLs, r.ShortUrl)
+	}
+
+	var links []*pbv2.Link
+	var pages int
+	req := &pbv2.ListURLsRequest{PageSize: 2}
+	for {
+		r, err := c.ListURLs(ctx, req)
+		require.NoError(t, err)
+		links = append(links, r.Links...)
+		pages++
+
+		if r.NextPageToken == "" {
+			break
+		}
+		req.PageToken = r.NextPageToken
+	}
+	assert.Equal(t, 2, pages)
+	require.Len(t, links, 3)
+	for _, l := range links {
+		assert.Contains(t, shortURLs, l.ShortUrl)
+		assert.Equal(t, pbv2.QueryPolicy_QUERY_POLICY_KEEP, l.Options.QueryPolicy)
+		assert.NotNil(t, l.CreateTime)
+	}
+
+	code := links[0].ShortUrl[len("http://localhost:8080/"):]
+
+	tests := []struct {
+		name    string
+		options *pbv2.LinkOptions
+		paths   []string
+		want    *pbv2.LinkOptions
+		code    codes.Code
+	}{
+		{
+			name:    "Test case #1",
+			options: &pbv2.LinkOptions{Title: "Practicum"},
+			paths:   []string{"title"},
+			want:    &pbv2.LinkOptions{Title: "Practicum", QueryPolicy: pbv2.QueryPolicy_QUERY_POLICY_KEEP},
+		},
+		{
+			name:    "Test case #2",
+			options: &pbv2.LinkOptions{Title: "Market"},
+			want:    &pbv2.LinkOptions{Title: "Market"},
+		},
+		{
+			name:    "Test case #3",
+			options: &pbv2.LinkOptions{Title: "Market"},
+			paths:   []string{"title", "owner"},
+			code:    codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := c.UpdateURLOptions(ctx, &pbv2.UpdateURLOptionsRequest{
+				ShortUrl:   code,
+				Options:    tt.options,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: tt.paths},
+			})
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code != codes.OK {
+				return
+			}
+			assert.Equal(t, tt.want.Title, o.Title)
+			assert.Equal(t, tt.want.QueryPolicy, o.QueryPolicy)
+
+			o, err = c.GetURLOptions(ctx, &pbv2.GetURLOptionsRequest{ShortUrl: code})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Title, o.Title)
+		})
+	}
+
+	e, err := c.ExpandURL(ctx, &pbv2.ExpandURLRequest{ShortUrl: code})
+	require.NoError(t, err)
+	assert.Equal(t, pbv2.LinkState_LINK_STATE_ACTIVE, e.State)
+	assert.Equal(t, links[0].OriginalUrl, e.OriginalUrl)
+	assert.NotNil(t, e.CreateTime)
+
+	_, err = c.ListURLs(ctx, &pbv2.ListURLsRequest{PageToken: "%%%"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = c.ExpandURL(ctx, &pbv2.ExpandURLRequest{ShortUrl: "asdf"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"net/http"
+	"strings"
+
+	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc/codes"
+)
+
+// NewWebHandler returns handler, that serves gRPC-Web requests of
+// browsers to Shortener services of both versions by server and other requests by
+// handler. gRPC-Web requests are served by the same server as native
+// gRPC, so they pass the same interceptors and get the same status
+// codes. Cross-origin requests are accepted from origins, "*" allows
+// any origin. If origins are empty, gRPC-Web is disabled and handler
+// is returned as is. If requireClientCert is set, gRPC-Web requests
+// without verified client certificate are rejected, as native gRPC
+// requests are.
+//
+// Server of NewWebHandler serves requests of HTTP server, so HTTP
+// server should be shut down before server is stopped by Stop of s.
+func NewWebHandler(s *HTTPServer, h http.Handler, origins []string, requireClientCert bool) http.Handler {
+	if len(origins) == 0 {
+		return h
+	}
+
+	allowed := make(map[string]struct{}, len(origins))
+	for _, o := range origins {
+		allowed[o] = struct{}{}
+	}
+
+	web := grpcweb.WrapServer(s.s,
+		grpcweb.WithOriginFunc(func(origin string) bool {
+			if _, ok := allowed["*"]; ok {
+				return true
+			}
+			_, ok := allowed[origin]
+			return ok
+		}),
+	)
+
+	prefixes := []string{
+		"/" + pb.Shortener_ServiceDesc.ServiceName + "/",
+		"/" + pbv2.Shortener_ServiceDesc.ServiceName + "/",
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hasAnyPrefix(r.URL.Path, prefixes) && (web.IsGrpcWebRequest(r) || web.IsAcceptableGrpcCorsRequest(r)) {
+			if requireClientCert && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+				writeStatus(w, r, codes.Unauthenticated, "client certificate is required")
+				return
+			}
+
+			s.serve(w, r, web)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// hasAnyPrefix reports whether s begins with any of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	pb "github.com/Fe4p3b/url-shortener/internal/handlers/grpc/proto"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestNewWebHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http"))
+	})
+	srv := httptest.NewServer(NewWebHandler(NewHTTPServer(s), h, []string{"https://dashboard.example.com"}, false))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		req    proto.Message
+		token  string
+		code   codes.Code
+		header string
+	}{
+		{name: "Test case #1", method: "ExpandURL", req: &pb.ExpandURLRequest{ShortUrl: "asdf"}, code: codes.NotFound},
+		{name: "Test case #2", method: "PostURL", req: &pb.PostURLRequest{OriginalUrl: "https://yandex.ru"}, token: "user", code: codes.OK},
+		{name: "Test case #3", method: "PostURL", req: &pb.PostURLRequest{OriginalUrl: "https://yandex.ru"}, code: codes.OK, header: "token-new"},
+		{name: "Test case #4", method: "PostURL", req: &pb.PostURLRequest{OriginalUrl: "https://yandex.ru"}, token: "stranger", code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := proto.Marshal(tt.req)
+			require.NoError(t, err)
+
+			body := make([]byte, 5, 5+len(msg))
+			binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+			body = append(body, msg...)
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL+fullMethod(tt.method), bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/grpc-web+proto")
+			req.Header.Set("Origin", "https://dashboard.example.com")
+			if tt.token != "" {
+				req.Header.Set(TokenKey, tt.token)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "https://dashboard.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, strconv.Itoa(int(tt.code)), webStatus(resp.Header, buf.Bytes()))
+			assert.Equal(t, tt.header, resp.Header.Get(TokenKey))
+		})
+	}
+
+	resp, err := http.Get(srv.URL + fullMethod("ExpandURL"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "http", buf.String())
+}
+
+func TestNewWebHandler_disabled(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http"))
+	})
+	srv := httptest.NewServer(NewWebHandler(NewHTTPServer(newTestServer(t)), h, nil, false))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+fullMethod("ExpandURL"), bytes.NewReader(make([]byte, 5)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "http", buf.String())
+}
+
+func TestNewWebHandler_requireClientCert(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http"))
+	})
+	srv := httptest.NewServer(NewWebHandler(NewHTTPServer(newTestServer(t)), h, []string{"*"}, true))
+	defer srv.Close()
+
+	msg, err := proto.Marshal(&pb.ExpandURLRequest{ShortUrl: "asdf"})
+	require.NoError(t, err)
+
+	body := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	body = append(body, msg...)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+fullMethod("ExpandURL"), bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, strconv.Itoa(int(codes.Unauthenticated)), resp.Header.Get("Grpc-Status"))
+}
+
+// webStatus returns grpc-status of gRPC-Web response, that is sent in
+// header or in trailer frame of body.
+func webStatus(header http.Header, body []byte) string {
+	if s := header.Get("Grpc-Status"); s != "" {
+		return s
+	}
+
+	for len(body) >= 5 {
+		n := binary.BigEndian.Uint32(body[1:5])
+		frame := body[5 : 5+n]
+		if body[0]&0x80 != 0 {
+			for _, line := range bytes.Split(frame, []byte("\r\n")) {
+				if kv := bytes.SplitN(line, []byte(":"), 2); len(kv) == 2 && string(bytes.ToLower(kv[0])) == "grpc-status" {
+					return string(bytes.TrimSpace(kv[1]))
+				}
+			}
+		}
+		body = body[5+n:]
+	}
+
+	return ""
+}
@@ -2,10 +2,17 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"log"
+	"net"
 
+	"github.com/Fe4p3b/url-shortener/internal/app/redirect"
 	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/geoip"
 	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/qr"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgconn"
@@ -14,17 +21,38 @@ import (
 
 var (
 	ErrorURLIsGone                   = errors.New("URL is gone")
+	ErrorURLIsDisabled               = errors.New("URL is disabled")
 	ErrorUniqueURLViolation          = errors.New("URL already exists")
 	ErrorNoContent                   = errors.New("no content")
+	ErrorInvalidPageToken            = errors.New("invalid page token")
 	_                       Handlers = &handler{}
 )
 
 type Handlers interface {
-	GetURL(string) (*repositories.URL, error)
-	PostURL(u string, user string) (string, error)
+	GetURL(string, *models.Visit) (*repositories.URL, error)
+	PostURL(u *models.URL) (string, error)
+	ExpandURL(shortURL string) (*models.Expansion, error)
+	GetQR(shortURL string, o qr.Options) ([]byte, error)
 	GetUserURLs(user string) ([]repositories.URL, error)
+	ListUserURLs(user string, fn func(repositories.URL) error) error
+	GetUserURLsPage(user string, after string, limit int) ([]repositories.URL, string, error)
 	DeleteUserURLs(user string, URLs []string)
 	ShortenBatch(user string, batch *[]repositories.URL) ([]repositories.URL, error)
+	GetTargeting(user string, shortURL string) ([]models.TargetingRule, error)
+	SetTargeting(user string, shortURL string, rules []models.TargetingRule) error
+	GetGeo(user string, shortURL string) ([]models.GeoRule, error)
+	SetGeo(user string, shortURL string, rules []models.GeoRule) error
+	GetVariants(user string, shortURL string) ([]models.VariantStats, error)
+	SetVariants(user string, shortURL string, variants []models.Variant) error
+	GetOptions(user string, shortURL string) (*models.Options, error)
+	UpdateOptions(user string, shortURL string, update func(o *models.Options)) (*models.Options, error)
+	ReportURL(r *models.Report) error
+	GetReports(status models.ReportStatus) ([]models.Report, error)
+	DisableURL(shortURL string, reason string, actor string) error
+	RestoreURL(shortURL string, actor string) error
+	FlagURL(shortURL string, reason string, actor string) error
+	UnflagURL(shortURL string, actor string) error
+	GetAuditLog(shortURL string) ([]models.AuditEntry, error)
 	Ping() error
 	GetStats() (*models.Stats, error)
 }
@@ -33,17 +61,31 @@ type Handlers interface {
 type handler struct {
 	s      shortener.ShortenerService
 	Router *chi.Mux
+
+	// geo finds country of visitor for geo rules, if it is nil
+	// geo rules are not used.
+	geo geoip.Locator
+
+	// qr keeps rendered QR codes of short URLs.
+	qr *qr.Cache
 }
 
 func NewHandler(s shortener.ShortenerService) *handler {
 	return &handler{
 		s:      s,
 		Router: chi.NewRouter(),
+		qr:     qr.NewCache(qr.DefaultCacheSize),
 	}
 }
 
-// GetURL redirects to original URL by short URL.
-func (h *handler) GetURL(shortURL string) (*repositories.URL, error) {
+// SetGeoIP sets locator, that is used to find country of visitor.
+func (h *handler) SetGeoIP(geo geoip.Locator) {
+	h.geo = geo
+}
+
+// GetURL finds original URL by short URL. If visit is provided,
+// URL of the result is a destination of redirect for the visit.
+func (h *handler) GetURL(shortURL string, v *models.Visit) (*repositories.URL, error) {
 	url, err := h.s.Find(shortURL)
 	if err != nil {
 		return nil, err
@@ -53,17 +95,80 @@ func (h *handler) GetURL(shortURL string) (*repositories.URL, error) {
 		return nil, ErrorURLIsGone
 	}
 
+	if url.DisabledReason != "" {
+		return nil, ErrorURLIsDisabled
+	}
+
+	url.ShortURL = shortURL
+	if v != nil && v.Country == "" && len(url.Geo) > 0 && h.geo != nil {
+		v.Country, err = h.geo.Country(net.ParseIP(v.IP))
+		if err != nil {
+			log.Printf("error finding country of %s: %v", v.IP, err)
+		}
+	}
+
+	url.URL, err = redirect.Destination(url, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if v != nil && v.Variant != "" {
+		if err := h.s.AddClick(shortURL, v.Variant); err != nil {
+			log.Printf("error counting click of %s: %v", shortURL, err)
+		}
+	}
+
 	return url, nil
 }
 
-// PostURL creates short URL by original URL.
-func (h *handler) PostURL(u string, user string) (string, error) {
-	sURL, err := h.s.Store(&models.URL{URL: u, UserID: user})
+// ExpandURL describes where short URL leads without redirect.
+func (h *handler) ExpandURL(shortURL string) (*models.Expansion, error) {
+	return h.s.Expand(shortURL)
+}
+
+// GetQR renders QR code of short URL with base URL. Rendered images
+// are cached, but state of short URL is checked on each call.
+func (h *handler) GetQR(shortURL string, o qr.Options) ([]byte, error) {
+	if err := o.Normalize(); err != nil {
+		return nil, err
+	}
+
+	e, err := h.s.Expand(shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.State {
+	case models.LinkDeleted:
+		return nil, ErrorURLIsGone
+	case models.LinkDisabled:
+		return nil, ErrorURLIsDisabled
+	}
+
+	key := o.Key(e.ShortURL)
+	if b, ok := h.qr.Get(key); ok {
+		return b, nil
+	}
+
+	b, err := qr.Render(e.ShortURL, o)
+	if err != nil {
+		return nil, err
+	}
+
+	h.qr.Put(key, b)
+	return b, nil
+}
+
+// PostURL creates short URL by original URL. If original URL is
+// already shortened, existing short URL is returned with
+// ErrorUniqueURLViolation.
+func (h *handler) PostURL(u *models.URL) (string, error) {
+	sURL, err := h.s.Store(u)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			return "", ErrorUniqueURLViolation
+			return sURL, ErrorUniqueURLViolation
 		}
 		return "", err
 	}
@@ -85,6 +190,36 @@ func (h *handler) GetUserURLs(user string) ([]repositories.URL, error) {
 	return URLs, nil
 }
 
+// ListUserURLs calls fn for each URL of user, unlike GetUserURLs
+// URLs aren't loaded at once.
+func (h *handler) ListUserURLs(user string, fn func(repositories.URL) error) error {
+	return h.s.ListUserURLs(user, fn)
+}
+
+// GetUserURLsPage returns page of URLs of user, that go after short
+// URL, and short URL, that the next page goes after, it is empty for
+// the last page.
+func (h *handler) GetUserURLsPage(user string, after string, limit int) ([]repositories.URL, string, error) {
+	return h.s.GetUserURLsPage(user, after, limit)
+}
+
+// EncodePageToken returns page token of page, that goes after short
+// URL, it is empty for empty short URL.
+func EncodePageToken(after string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(after))
+}
+
+// DecodePageToken returns short URL, that page of page token goes
+// after.
+func DecodePageToken(token string) (string, error) {
+	after, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrorInvalidPageToken, err)
+	}
+
+	return string(after), nil
+}
+
 // DeleteUserURLs deletes user URLs by short URL.
 func (h *handler) DeleteUserURLs(user string, URLs []string) {
 	h.s.DeleteURLs(user, URLs)
@@ -100,6 +235,132 @@ func (h *handler) ShortenBatch(user string, batch *[]repositories.URL) ([]reposi
 	return sURLBatch, nil
 }
 
+// GetTargeting returns targeting rules of user's short URL.
+func (h *handler) GetTargeting(user string, shortURL string) ([]models.TargetingRule, error) {
+	o, err := h.s.GetOptions(user, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Targeting, nil
+}
+
+// SetTargeting replaces targeting rules of user's short URL.
+func (h *handler) SetTargeting(user string, shortURL string, rules []models.TargetingRule) error {
+	_, err := h.s.UpdateOptions(user, shortURL, func(o *models.Options) {
+		o.Targeting = rules
+	})
+
+	return err
+}
+
+// GetGeo returns geo rules of user's short URL.
+func (h *handler) GetGeo(user string, shortURL string) ([]models.GeoRule, error) {
+	o, err := h.s.GetOptions(user, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Geo, nil
+}
+
+// SetGeo replaces geo rules of user's short URL.
+func (h *handler) SetGeo(user string, shortURL string, rules []models.GeoRule) error {
+	_, err := h.s.UpdateOptions(user, shortURL, func(o *models.Options) {
+		o.Geo = rules
+	})
+
+	return err
+}
+
+// GetVariants returns variants of user's short URL with number
+// of visits, that each variant was served.
+func (h *handler) GetVariants(user string, shortURL string) ([]models.VariantStats, error) {
+	o, err := h.s.GetOptions(user, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	clicks, err := h.s.GetClicks(shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.VariantStats, 0, len(o.Variants))
+	for _, v := range o.Variants {
+		stats = append(stats, models.VariantStats{Variant: v, Clicks: clicks[v.Name]})
+	}
+
+	return stats, nil
+}
+
+// SetVariants replaces variants of user's short URL. Number of visits
+// of variants, that are kept by name, is preserved.
+func (h *handler) SetVariants(user string, shortURL string, variants []models.Variant) error {
+	_, err := h.s.UpdateOptions(user, shortURL, func(o *models.Options) {
+		o.Variants = variants
+	})
+
+	return err
+}
+
+// GetOptions returns options of user's short URL.
+func (h *handler) GetOptions(user string, shortURL string) (*models.Options, error) {
+	return h.s.GetOptions(user, shortURL)
+}
+
+// UpdateOptions replaces options of user's short URL by options, that
+// update sets to the current options, and returns stored options.
+func (h *handler) UpdateOptions(user string, shortURL string, update func(o *models.Options)) (*models.Options, error) {
+	return h.s.UpdateOptions(user, shortURL, update)
+}
+
+// ReportURL adds abuse report of short URL to moderation queue.
+func (h *handler) ReportURL(r *models.Report) error {
+	return moderationError(h.s.Report(r))
+}
+
+// GetReports returns reports of moderation queue by status.
+func (h *handler) GetReports(status models.ReportStatus) ([]models.Report, error) {
+	return h.s.GetReports(status)
+}
+
+// DisableURL disables short URL by moderator with reason, that is
+// shown to visitors.
+func (h *handler) DisableURL(shortURL string, reason string, actor string) error {
+	return moderationError(h.s.Disable(shortURL, reason, actor))
+}
+
+// RestoreURL enables short URL, that was disabled.
+func (h *handler) RestoreURL(shortURL string, actor string) error {
+	return moderationError(h.s.Restore(shortURL, actor))
+}
+
+// FlagURL flags short URL by moderator, so visitors are warned
+// before redirect.
+func (h *handler) FlagURL(shortURL string, reason string, actor string) error {
+	return moderationError(h.s.Flag(shortURL, reason, actor))
+}
+
+// UnflagURL removes flag of moderator from short URL.
+func (h *handler) UnflagURL(shortURL string, actor string) error {
+	return moderationError(h.s.Unflag(shortURL, actor))
+}
+
+// GetAuditLog returns audit log of moderation.
+func (h *handler) GetAuditLog(shortURL string) ([]models.AuditEntry, error) {
+	return h.s.GetAuditLog(shortURL)
+}
+
+// moderationError reports deleted short URL by ErrorURLIsGone.
+func moderationError(err error) error {
+	if errors.Is(err, shortener.ErrorURLIsDeleted) {
+		return ErrorURLIsGone
+	}
+
+	return err
+}
+
 // Ping checks whether database connetion is up.
 func (h *handler) Ping() error {
 	if err := h.s.Ping(); err != nil {
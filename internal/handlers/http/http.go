@@ -2,53 +2,120 @@
 package http
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/pprof"
-	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/Fe4p3b/url-shortener/internal/app/redirect"
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
 	"github.com/Fe4p3b/url-shortener/internal/handlers"
 	"github.com/Fe4p3b/url-shortener/internal/middleware"
 	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/qr"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
 	"github.com/Fe4p3b/url-shortener/internal/serializers"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	// variantCookiePrefix is a prefix of cookie name, that keeps
+	// variant of short URL, that was served to visitor.
+	variantCookiePrefix = "variant_"
+
+	// variantCookieMaxAge is a lifetime of variant cookie in seconds.
+	variantCookieMaxAge = 30 * 24 * 60 * 60
+
+	// qrCacheControl allows clients to cache QR codes for a day.
+	qrCacheControl = "public, max-age=86400"
+
+	// NextPageTokenHeader is a header of page of user URLs, that
+	// keeps token of the next page.
+	NextPageTokenHeader = "X-Next-Page-Token"
+
+	// MaxPageSize is a maximum number of URLs of page of user URLs.
+	MaxPageSize = 1000
+)
+
 // handler provides handlers for http endpoints.
 type httpHandler struct {
 	Router *chi.Mux
 	h      handlers.Handlers
+
+	// templates render html pages.
+	templates templates
+
+	// policy resolves IP address of client, if it is nil, address
+	// of peer is used.
+	policy *middleware.TrustedPolicy
 }
 
 func NewHandler(h handlers.Handlers) *httpHandler {
 	return &httpHandler{
-		Router: chi.NewRouter(),
-		h:      h,
+		Router:    chi.NewRouter(),
+		h:         h,
+		templates: defaultTemplates(),
 	}
 }
 
+// SetTrustedPolicy sets policy, that decides whether X-Real-IP of
+// request is trusted.
+func (h *httpHandler) SetTrustedPolicy(policy *middleware.TrustedPolicy) {
+	h.policy = policy
+}
+
+// clientIP returns IP address of client, X-Real-IP is used only, if
+// it is passed by trusted proxy.
+func (h *httpHandler) clientIP(r *http.Request) string {
+	policy := h.policy
+	if policy == nil {
+		policy = &middleware.TrustedPolicy{}
+	}
+
+	return policy.ClientIP(r.RemoteAddr, r.Header.Get("X-Real-IP"))
+}
+
 // SetupAPIRouting initializes http routes for api.
 func (h *httpHandler) SetupAPIRouting() {
 	h.Router.Get("/{url}", h.GetURL)
 	h.Router.Post("/", h.PostURL)
 	h.Router.Post("/api/shorten", h.JSONPost)
+	h.Router.Get("/api/expand/{url}", h.ExpandURL)
+	h.Router.Get("/api/qr/{url}", h.GetQR)
+	h.Router.Get("/report/{url}", h.ReportForm)
+	h.Router.Post("/report/{url}", h.ReportPage)
+	h.Router.Post("/api/report/{url}", h.ReportURL)
 
 	h.Router.Post("/api/shorten/batch", h.ShortenBatch)
 	h.Router.Get("/ping", h.Ping)
 
 	h.Router.Get("/user/urls", h.GetUserURLs)
 	h.Router.Delete("/api/user/urls", h.DeleteUserURLs)
+	h.Router.Get("/api/user/urls/{url}/targeting", h.GetTargeting)
+	h.Router.Put("/api/user/urls/{url}/targeting", h.SetTargeting)
+	h.Router.Get("/api/user/urls/{url}/geo", h.GetGeo)
+	h.Router.Put("/api/user/urls/{url}/geo", h.SetGeo)
+	h.Router.Get("/api/user/urls/{url}/variants", h.GetVariants)
+	h.Router.Put("/api/user/urls/{url}/variants", h.SetVariants)
 }
 
-func (h *httpHandler) SetupInternalRouting(IPs []string) {
+func (h *httpHandler) SetupInternalRouting(policy *middleware.TrustedPolicy) {
 	r := chi.NewRouter()
 
-	t := middleware.NewTrustedNetworksOnlyMiddleware(IPs)
+	t := middleware.NewTrustedNetworksOnlyMiddleware(policy)
 
 	r.Use(t.Middleware)
 	r.Get("/stats", h.GetStats)
+	r.Get("/reports", h.GetReports)
+	r.Get("/audit", h.GetAuditLog)
+	r.Post("/urls/{url}/disable", h.DisableURL)
+	r.Post("/urls/{url}/restore", h.RestoreURL)
+	r.Post("/urls/{url}/flag", h.FlagURL)
+	r.Post("/urls/{url}/unflag", h.UnflagURL)
 
 	h.Router.Mount("/api/internal", r)
 }
@@ -78,8 +145,30 @@ func (h *httpHandler) GetURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url, err := h.h.GetURL(q)
+	if code, ok := previewCode(r, q); ok {
+		h.Preview(w, r, code)
+		return
+	}
+
+	v := &models.Visit{
+		Query:          r.URL.Query(),
+		Referer:        r.Referer(),
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		IP:             h.clientIP(r),
+		Time:           time.Now(),
+	}
+
+	if c, err := r.Cookie(variantCookiePrefix + q); err == nil {
+		v.Variant = c.Value
+	}
+
+	url, err := h.h.GetURL(q, v)
 	if err != nil {
+		if errors.Is(err, handlers.ErrorURLIsDisabled) {
+			h.Preview(w, r, q)
+			return
+		}
 		if errors.Is(err, handlers.ErrorURLIsGone) {
 			http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
 			return
@@ -88,9 +177,174 @@ func (h *httpHandler) GetURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if v.Variant != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     variantCookiePrefix + q,
+			Value:    v.Variant,
+			Path:     "/" + q,
+			MaxAge:   variantCookieMaxAge,
+			HttpOnly: true,
+		})
+	}
+
+	if len(url.Flags) > 0 {
+		h.Interstitial(w, q, url.URL, url.Flags)
+		return
+	}
+
 	http.Redirect(w, r, url.URL, http.StatusTemporaryRedirect)
 }
 
+// Preview shows page, that describes where short URL leads, instead
+// of redirect. Page of deleted or disabled short URL is shown with
+// 410 code, reason of disabling is shown to visitors.
+func (h *httpHandler) Preview(w http.ResponseWriter, r *http.Request, shortURL string) {
+	e, err := h.h.ExpandURL(shortURL)
+	if err != nil {
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := h.templates.preview.Execute(&b, preview{Expansion: e, Code: shortURL}); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(expansionStatus(e))
+	_, err = w.Write(b.Bytes())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// ExpandURL shows where short URL leads in json without redirect.
+// Deleted or disabled short URL is shown with its state and 410 code.
+func (h *httpHandler) ExpandURL(w http.ResponseWriter, r *http.Request) {
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	e, err := h.h.ExpandURL(chi.URLParam(r, "url"))
+	if err != nil {
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := s.Encode(e)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(expansionStatus(e))
+	_, err = w.Write(b)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetQR shows QR code of short URL. Format, size in pixels, error
+// correction level and label with short URL are set by "format",
+// "size", "level" and "label" query parameters.
+func (h *httpHandler) GetQR(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	o := qr.Options{
+		Format: qr.Format(q.Get("format")),
+		Level:  q.Get("level"),
+		Label:  q.Get("label") == "1",
+	}
+
+	if size := q.Get("size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			http.Error(w, "size should be a number", http.StatusBadRequest)
+			return
+		}
+		o.Size = n
+	}
+
+	b, err := h.h.GetQR(chi.URLParam(r, "url"), o)
+	if err != nil {
+		if errors.Is(err, qr.ErrorInvalidOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, handlers.ErrorURLIsGone) || errors.Is(err, handlers.ErrorURLIsDisabled) {
+			http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+			return
+		}
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "image/png"
+	if o.Format == qr.SVG {
+		contentType = "image/svg+xml"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", qrCacheControl)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeValidationError writes errors of invalid fields of request
+// in json with 400 code.
+func writeValidationError(w http.ResponseWriter, vErr *shortener.ValidationError) {
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := s.Encode(vErr)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_, err = w.Write(b)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// expansionStatus returns response code for state of short URL.
+func expansionStatus(e *models.Expansion) int {
+	if e.State != models.LinkActive {
+		return http.StatusGone
+	}
+
+	return http.StatusOK
+}
+
 // PostURL creates short URL by original URL.
 func (h *httpHandler) PostURL(w http.ResponseWriter, r *http.Request) {
 	user, ok := r.Context().Value(middleware.Key).(string)
@@ -105,21 +359,16 @@ func (h *httpHandler) PostURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u := string(b)
-
-	_, err = url.Parse(u)
-	if err != nil || len(u) == 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
-
-	sURL, err := h.h.PostURL(u, user)
+	sURL, err := h.h.PostURL(&models.URL{URL: string(b), UserID: user})
 
 	header := http.StatusCreated
 
 	if err != nil {
 		if errors.Is(err, handlers.ErrorUniqueURLViolation) {
 			header = http.StatusConflict
+		} else if errors.Is(err, shortener.ErrorInvalidURL) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		} else {
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
@@ -160,20 +409,22 @@ func (h *httpHandler) JSONPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if url.URL == "" {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
-
 	url.UserID = user
 
-	sURL, err := h.h.PostURL(url.URL, user)
+	sURL, err := h.h.PostURL(url)
 
 	header := http.StatusCreated
 
 	if err != nil {
+		var vErr *shortener.ValidationError
 		if errors.Is(err, handlers.ErrorUniqueURLViolation) {
 			header = http.StatusConflict
+		} else if errors.As(err, &vErr) {
+			writeValidationError(w, vErr)
+			return
+		} else if errors.Is(err, redirect.ErrorInvalidOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		} else {
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
@@ -210,6 +461,11 @@ func (h *httpHandler) GetUserURLs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("page_size") != "" {
+		h.getUserURLsPage(w, r, user)
+		return
+	}
+
 	URLs, err := h.h.GetUserURLs(user)
 	if err != nil {
 		if errors.Is(err, handlers.ErrorNoContent) {
@@ -235,6 +491,41 @@ func (h *httpHandler) GetUserURLs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getUserURLsPage shows page of user URLs in json. Page is set by
+// "page_size" and "page_token" query parameters, token of the next
+// page is passed in NextPageTokenHeader, unless page is the last.
+func (h *httpHandler) getUserURLsPage(w http.ResponseWriter, r *http.Request, user string) {
+	size, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || size <= 0 || size > MaxPageSize {
+		http.Error(w, "invalid page size", http.StatusBadRequest)
+		return
+	}
+
+	after, err := handlers.DecodePageToken(r.URL.Query().Get("page_token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	URLs, next, err := h.h.GetUserURLsPage(user, after, size)
+	if errors.Is(err, shortener.ErrorInvalidPageSize) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if next != "" {
+		w.Header().Set(NextPageTokenHeader, handlers.EncodePageToken(next))
+	}
+	if URLs == nil {
+		URLs = []repositories.URL{}
+	}
+	writeJSON(w, http.StatusOK, URLs)
+}
+
 // DeleteUserURLs deletes user URLs by short URL.
 func (h *httpHandler) DeleteUserURLs(w http.ResponseWriter, r *http.Request) {
 	user, ok := r.Context().Value(middleware.Key).(string)
@@ -294,6 +585,15 @@ func (h *httpHandler) ShortenBatch(w http.ResponseWriter, r *http.Request) {
 
 	sURLBatch, err := h.h.ShortenBatch(user, batch)
 	if err != nil {
+		var vErr *shortener.ValidationError
+		if errors.As(err, &vErr) {
+			writeValidationError(w, vErr)
+			return
+		}
+		if errors.Is(err, redirect.ErrorInvalidOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -313,6 +613,272 @@ func (h *httpHandler) ShortenBatch(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetTargeting shows targeting rules of user's short URL in json.
+func (h *httpHandler) GetTargeting(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.Key).(string)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rules, err := h.h.GetTargeting(user, chi.URLParam(r, "url"))
+	if err != nil {
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []models.TargetingRule{}
+	}
+
+	b, err := s.Encode(rules)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SetTargeting replaces targeting rules of user's short URL by rules in json.
+func (h *httpHandler) SetTargeting(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.Key).(string)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rules := make([]models.TargetingRule, 0)
+	if err := s.Decode(b, &rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.h.SetTargeting(user, chi.URLParam(r, "url"), rules); err != nil {
+		var vErr *shortener.ValidationError
+		if errors.As(err, &vErr) {
+			writeValidationError(w, vErr)
+			return
+		}
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, redirect.ErrorInvalidOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetGeo shows geo rules of user's short URL in json.
+func (h *httpHandler) GetGeo(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.Key).(string)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rules, err := h.h.GetGeo(user, chi.URLParam(r, "url"))
+	if err != nil {
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []models.GeoRule{}
+	}
+
+	b, err := s.Encode(rules)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SetGeo replaces geo rules of user's short URL by rules in json.
+func (h *httpHandler) SetGeo(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.Key).(string)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rules := make([]models.GeoRule, 0)
+	if err := s.Decode(b, &rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.h.SetGeo(user, chi.URLParam(r, "url"), rules); err != nil {
+		var vErr *shortener.ValidationError
+		if errors.As(err, &vErr) {
+			writeValidationError(w, vErr)
+			return
+		}
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, redirect.ErrorInvalidOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetVariants shows variants of user's short URL with number of visits in json.
+func (h *httpHandler) GetVariants(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.Key).(string)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	variants, err := h.h.GetVariants(user, chi.URLParam(r, "url"))
+	if err != nil {
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := s.Encode(variants)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SetVariants replaces variants of user's short URL by variants in json.
+func (h *httpHandler) SetVariants(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.Key).(string)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	variants := make([]models.Variant, 0)
+	if err := s.Decode(b, &variants); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.h.SetVariants(user, chi.URLParam(r, "url"), variants); err != nil {
+		var vErr *shortener.ValidationError
+		if errors.As(err, &vErr) {
+			writeValidationError(w, vErr)
+			return
+		}
+		if errors.Is(err, storage.ErrorNoLinkFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, redirect.ErrorInvalidOptions) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // Ping checks whether database connetion is up.
 func (h *httpHandler) Ping(w http.ResponseWriter, r *http.Request) {
 	if err := h.h.Ping(); err != nil {
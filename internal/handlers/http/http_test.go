@@ -6,15 +6,20 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
 	"github.com/Fe4p3b/url-shortener/internal/handlers"
 	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/storage/memory"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -242,9 +247,24 @@ func Test_handler_PostURL(t *testing.T) {
 				body:   "yandex.ru",
 			},
 			want: want{
-				code:     http.StatusCreated,
-				response: "",
-				err:      false,
+				code:     http.StatusBadRequest,
+				response: "invalid URL: url: should be an absolute URL\n",
+				err:      true,
+			},
+		},
+		{
+			name: "test case #3",
+			fields: fields{
+				s:      s,
+				h:      h,
+				method: http.MethodPost,
+				url:    "/",
+				body:   "javascript:alert(1)",
+			},
+			want: want{
+				code:     http.StatusBadRequest,
+				response: "invalid URL: url: scheme \"javascript\" is not allowed, use http or https\n",
+				err:      true,
 			},
 		},
 	}
@@ -323,9 +343,9 @@ func Test_handler_JSONPost(t *testing.T) {
 				contentType: "application/json",
 			},
 			want: want{
-				code:        http.StatusCreated,
-				response:    "",
-				err:         false,
+				code:        http.StatusBadRequest,
+				response:    `{"errors":[{"field":"url","message":"should be an absolute URL"}]}`,
+				err:         true,
 				contentType: "application/json",
 			},
 		},
@@ -341,7 +361,24 @@ func Test_handler_JSONPost(t *testing.T) {
 			},
 			want: want{
 				code:        http.StatusBadRequest,
-				response:    "Bad Request\n",
+				response:    `{"errors":[{"field":"url","message":"is required"}]}`,
+				err:         true,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "test case #4",
+			fields: fields{
+				s:           s,
+				h:           h,
+				method:      http.MethodPost,
+				url:         "/api/shorten",
+				body:        `{"url":"https://yandex.ru","utm":{"utm_source":"{unknown}"}}`,
+				contentType: "application/json",
+			},
+			want: want{
+				code:        http.StatusBadRequest,
+				response:    "",
 				err:         true,
 				contentType: "text/plain; charset=utf-8",
 			},
@@ -372,6 +409,7 @@ func Test_handler_GetUserURLs(t *testing.T) {
 	m := memory.NewMemory(map[string]string{
 		"asdf": "yandex.ru",
 	})
+	m.Restore("asdf", "asdfg", models.Options{}, time.Time{})
 	s := shortener.NewShortener(m, "http://localhost:8080")
 	h := handlers.NewHandler(s)
 
@@ -413,6 +451,37 @@ func Test_handler_GetUserURLs(t *testing.T) {
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
+		{
+			name: "test case #2",
+			fields: fields{
+				s:           s,
+				h:           h,
+				method:      http.MethodGet,
+				url:         "/user/urls?page_size=10",
+				contentType: "application/json",
+				token:       "asdfg",
+			},
+			want: want{
+				code:        http.StatusOK,
+				response:    `[{"original_url":"yandex.ru","short_url":"http://localhost:8080/asdf"}]`,
+				contentType: "application/json",
+			},
+		},
+		{
+			name: "test case #3",
+			fields: fields{
+				s:           s,
+				h:           h,
+				method:      http.MethodGet,
+				url:         "/user/urls?page_size=abc",
+				contentType: "application/json",
+				token:       "asdfg",
+			},
+			want: want{
+				code:        http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -635,3 +704,378 @@ func Test_handler_Ping(t *testing.T) {
 		})
 	}
 }
+
+func Test_handler_ExpandURL(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	err := m.Save(&models.URL{URL: "http://yandex.ru", ShortURL: "asdf", Options: models.Options{Title: "Yandex"}})
+	assert.NoError(t, err)
+
+	s := shortener.NewShortener(m, "http://localhost:8080")
+	h := NewHandler(handlers.NewHandler(s))
+	h.SetupAPIRouting()
+
+	tests := []struct {
+		name        string
+		url         string
+		code        int
+		contentType string
+		contains    []string
+	}{
+		{
+			name:        "Test case #1",
+			url:         "/asdf+",
+			code:        http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			contains:    []string{"<title>Yandex</title>", `href="http://yandex.ru"`, "Created on "},
+		},
+		{
+			name:        "Test case #2",
+			url:         "/asdf?preview=1",
+			code:        http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			contains:    []string{"http://localhost:8080/asdf leads to"},
+		},
+		{
+			name:        "Test case #3",
+			url:         "/qwerty+",
+			code:        http.StatusNotFound,
+			contentType: "text/plain; charset=utf-8",
+			contains:    []string{"Not Found"},
+		},
+		{
+			name:        "Test case #4",
+			url:         "/api/expand/asdf",
+			code:        http.StatusOK,
+			contentType: "application/json",
+			contains:    []string{`"short_url":"http://localhost:8080/asdf"`, `"url":"http://yandex.ru"`, `"title":"Yandex"`, `"state":"active"`},
+		},
+		{
+			name:        "Test case #5",
+			url:         "/api/expand/qwerty",
+			code:        http.StatusNotFound,
+			contentType: "text/plain; charset=utf-8",
+			contains:    []string{"Not Found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			h.Router.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			for _, c := range tt.contains {
+				assert.Contains(t, w.Body.String(), c)
+			}
+		})
+	}
+}
+
+func Test_handler_GetQR(t *testing.T) {
+	m := memory.NewMemory(map[string]string{"asdf": "http://yandex.ru"})
+	s := shortener.NewShortener(m, "http://localhost:8080")
+	h := NewHandler(handlers.NewHandler(s))
+	h.SetupAPIRouting()
+
+	tests := []struct {
+		name        string
+		url         string
+		code        int
+		contentType string
+	}{
+		{
+			name:        "Test case #1",
+			url:         "/api/qr/asdf",
+			code:        http.StatusOK,
+			contentType: "image/png",
+		},
+		{
+			name:        "Test case #2",
+			url:         "/api/qr/asdf?format=svg&size=512&level=H&label=1",
+			code:        http.StatusOK,
+			contentType: "image/svg+xml",
+		},
+		{
+			name:        "Test case #3",
+			url:         "/api/qr/asdf?size=big",
+			code:        http.StatusBadRequest,
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "Test case #4",
+			url:         "/api/qr/asdf?level=X",
+			code:        http.StatusBadRequest,
+			contentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "Test case #5",
+			url:         "/api/qr/qwerty",
+			code:        http.StatusNotFound,
+			contentType: "text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			h.Router.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func Test_handler_GetURL_Flagged(t *testing.T) {
+	m := memory.NewMemory(map[string]string{})
+	s := shortener.NewShortener(m, "http://localhost:8080")
+	h := NewHandler(handlers.NewHandler(s))
+	h.SetupAPIRouting()
+
+	u := &models.URL{URL: "https://аpple.com"}
+	_, err := s.Store(u)
+	assert.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/"+u.ShortURL, nil)
+	w := httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+	assert.Contains(t, w.Body.String(), `href="https://xn--pple-43d.com"`)
+	assert.Contains(t, w.Body.String(), "similar-looking letters")
+	assert.Contains(t, w.Body.String(), `class="continue" href="https://xn--pple-43d.com"`)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	request = httptest.NewRequest(http.MethodGet, "/"+u.ShortURL+"+", nil)
+	w = httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "flagged as suspicious (confusable, mixed_script)")
+}
+
+// countryLocator locates IP addresses by countries map.
+type countryLocator map[string]string
+
+func (l countryLocator) Country(ip net.IP) (string, error) {
+	return l[ip.String()], nil
+}
+
+func Test_handler_GetURL_Geo(t *testing.T) {
+	m := memory.NewMemory(map[string]string{"asdf": "http://yandex.ru"})
+	m.Restore("asdf", "user", models.Options{Geo: []models.GeoRule{{Countries: []string{"KZ"}, URL: "https://yandex.kz"}}}, time.Time{})
+
+	hs := handlers.NewHandler(shortener.NewShortener(m, "http://localhost:8080"))
+	hs.SetGeoIP(countryLocator{"203.0.113.7": "KZ"})
+
+	h := NewHandler(hs)
+	policy, err := middleware.NewTrustedPolicy(nil, []string{"192.0.2.10"})
+	assert.NoError(t, err)
+	h.SetTrustedPolicy(policy)
+	h.SetupAPIRouting()
+
+	tests := []struct {
+		name     string
+		remote   string
+		realIP   string
+		location string
+	}{
+		{name: "Test case #1", remote: "203.0.113.7:1234", location: "https://yandex.kz"},
+		{name: "Test case #2", remote: "198.51.100.1:1234", realIP: "203.0.113.7", location: "http://yandex.ru"},
+		{name: "Test case #3", remote: "192.0.2.10:1234", realIP: "203.0.113.7", location: "https://yandex.kz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/asdf", nil)
+			request.RemoteAddr = tt.remote
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			h.Router.ServeHTTP(w, request)
+
+			assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func Test_handler_Moderation(t *testing.T) {
+	m := memory.NewMemory(map[string]string{"asdf": "http://yandex.ru"})
+	s := shortener.NewShortener(m, "http://localhost:8080")
+	h := NewHandler(handlers.NewHandler(s))
+	h.SetupAPIRouting()
+	policy, err := middleware.NewTrustedPolicy([]string{"192.0.2.1"}, nil)
+	assert.NoError(t, err)
+	h.SetTrustedPolicy(policy)
+	h.SetupInternalRouting(policy)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		remote   string
+		realIP   string
+		code     int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "Test case #1",
+			method:   http.MethodGet,
+			url:      "/asdf+",
+			code:     http.StatusOK,
+			contains: []string{`href="/report/asdf"`},
+		},
+		{
+			name:     "Test case #2",
+			method:   http.MethodGet,
+			url:      "/report/asdf",
+			code:     http.StatusOK,
+			contains: []string{`<form method="post" action="/report/asdf">`},
+		},
+		{
+			name:     "Test case #3",
+			method:   http.MethodPost,
+			url:      "/api/report/asdf",
+			body:     `{"reason": "phishing"}`,
+			code:     http.StatusCreated,
+			contains: []string{`"short_url":"asdf"`, `"status":"open"`},
+		},
+		{
+			name:   "Test case #4",
+			method: http.MethodPost,
+			url:    "/api/report/asdf",
+			body:   `{"reason": ""}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "Test case #5",
+			method: http.MethodPost,
+			url:    "/api/report/qwerty",
+			body:   `{"reason": "spam"}`,
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "Test case #6",
+			method: http.MethodGet,
+			url:    "/api/internal/reports?status=open",
+			remote: "10.0.0.1:1234",
+			code:   http.StatusForbidden,
+		},
+		{
+			name:     "Test case #7",
+			method:   http.MethodGet,
+			url:      "/api/internal/reports?status=open",
+			code:     http.StatusOK,
+			contains: []string{`"reason":"phishing"`},
+		},
+		{
+			name:   "Test case #8",
+			method: http.MethodPost,
+			url:    "/api/internal/urls/asdf/disable",
+			body:   `{"reason": "phishing site"}`,
+			realIP: "203.0.113.9",
+			code:   http.StatusNoContent,
+		},
+		{
+			name:     "Test case #9",
+			method:   http.MethodGet,
+			url:      "/asdf",
+			code:     http.StatusGone,
+			contains: []string{"is disabled: phishing site."},
+		},
+		{
+			name:   "Test case #10",
+			method: http.MethodPost,
+			url:    "/api/internal/urls/asdf/restore",
+			code:   http.StatusNoContent,
+		},
+		{
+			name:   "Test case #11",
+			method: http.MethodGet,
+			url:    "/asdf",
+			code:   http.StatusTemporaryRedirect,
+		},
+		{
+			name:     "Test case #12",
+			method:   http.MethodGet,
+			url:      "/api/internal/audit?short_url=asdf",
+			code:     http.StatusOK,
+			contains: []string{`"action":"report"`, `"action":"disable"`, `"action":"restore"`, `"actor":"192.0.2.1"`},
+			excludes: []string{"203.0.113.9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.remote != "" {
+				request.RemoteAddr = tt.remote
+			}
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+
+			h.Router.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.code, w.Code)
+			for _, c := range tt.contains {
+				assert.Contains(t, w.Body.String(), c)
+			}
+			for _, c := range tt.excludes {
+				assert.NotContains(t, w.Body.String(), c)
+			}
+		})
+	}
+}
+
+func Test_handler_LoadTemplates(t *testing.T) {
+	m := memory.NewMemory(map[string]string{"asdf": "http://yandex.ru"})
+	s := shortener.NewShortener(m, "http://localhost:8080")
+	h := NewHandler(handlers.NewHandler(s))
+	h.SetupAPIRouting()
+	policy, err := middleware.NewTrustedPolicy([]string{"192.0.2.1"}, nil)
+	assert.NoError(t, err)
+	h.SetupInternalRouting(policy)
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "interstitial.html"), []byte(`Careful: {{range .Flags}}{{.Name}} {{end}}<a href="{{.URL}}">Go on</a>`), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, h.LoadTemplates(dir))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/internal/urls/asdf/flag", strings.NewReader(`{"reason": "scam"}`))
+	w := httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/asdf", nil)
+	w = httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `Careful: moderator <a href="http://yandex.ru">Go on</a>`, w.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "/asdf+", nil)
+	w = httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+	assert.Contains(t, w.Body.String(), "flagged as suspicious (moderator)")
+
+	request = httptest.NewRequest(http.MethodPost, "/api/internal/urls/asdf/unflag", nil)
+	w = httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/asdf", nil)
+	w = httptest.NewRecorder()
+	h.Router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	err = os.WriteFile(filepath.Join(dir, "report.html"), []byte(`{{.Broken`), 0644)
+	assert.NoError(t, err)
+	assert.Error(t, h.LoadTemplates(dir))
+}
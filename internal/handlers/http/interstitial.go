@@ -0,0 +1,86 @@
+package http
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/idn"
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+)
+
+// interstitialTemplate renders page, that warns visitor of flagged
+// short URL about the risk instead of redirect.
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Warning</title>
+<style>
+.continue { display: inline-block; padding: 8px 16px; border: 1px solid #555; border-radius: 4px; color: #000; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>This link may be unsafe</h1>
+<p>The destination was flagged as suspicious:</p>
+<ul>
+{{range .Flags}}<li>{{.Description}}</li>
+{{end}}</ul>
+<p>It leads to {{.URL}}</p>
+<p><a class="continue" href="{{.URL}}" rel="noopener noreferrer nofollow">Continue</a></p>
+<p><a href="/report/{{.Code}}">Report this link</a></p>
+</body>
+</html>
+`))
+
+// flagDescriptions describe flags of short URLs to visitors.
+var flagDescriptions = map[string]string{
+	idn.FlagMixedScript:     "the domain mixes letters of different alphabets",
+	idn.FlagConfusable:      "the domain imitates another domain by similar-looking letters",
+	shortener.FlagShortener: "the link leads to another shortener, that hides the real destination",
+	shortener.FlagModerator: "the link was flagged by moderators",
+}
+
+// interstitialFlag is a flag of short URL with its description.
+type interstitialFlag struct {
+	Name        string
+	Description string
+}
+
+// interstitial is a data of interstitialTemplate.
+type interstitial struct {
+	// Code is short URL without base URL.
+	Code string
+
+	// URL is a destination of redirect.
+	URL   string
+	Flags []interstitialFlag
+}
+
+// Interstitial shows page, that explains the risk of destination u of
+// flagged short URL and offers to continue, instead of redirect.
+func (h *httpHandler) Interstitial(w http.ResponseWriter, shortURL string, u string, flags []string) {
+	data := interstitial{Code: shortURL, URL: u}
+	for _, f := range flags {
+		d, ok := flagDescriptions[f]
+		if !ok {
+			d = f
+		}
+		data.Flags = append(data.Flags, interstitialFlag{Name: f, Description: d})
+	}
+
+	var b bytes.Buffer
+	if err := h.templates.interstitial.Execute(&b, data); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b.Bytes()); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
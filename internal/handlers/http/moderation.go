@@ -0,0 +1,287 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/Fe4p3b/url-shortener/internal/models"
+	"github.com/Fe4p3b/url-shortener/internal/serializers"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// reportTemplate renders form of abuse report of short URL and
+// confirmation of sent report.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Report link</title>
+</head>
+<body>
+<h1>Report link {{.Code}}</h1>
+{{if .Sent}}<p>Thank you, the report was sent to moderators.</p>
+{{else}}{{with .Error}}<p>{{.}}</p>
+{{end}}<form method="post" action="/report/{{.Code}}">
+<p><label for="reason">Why is this link abusive?</label></p>
+<p><textarea id="reason" name="reason" rows="5" cols="60" maxlength="500" required></textarea></p>
+<p><button type="submit">Report</button></p>
+</form>
+{{end}}</body>
+</html>
+`))
+
+// reportPage is a data of reportTemplate.
+type reportPage struct {
+	Code  string
+	Sent  bool
+	Error string
+}
+
+// reason is a request of reporter or moderator with reason.
+type reason struct {
+	Reason string `json:"reason"`
+}
+
+// ReportForm shows form of abuse report of short URL.
+func (h *httpHandler) ReportForm(w http.ResponseWriter, r *http.Request) {
+	h.writeReportPage(w, http.StatusOK, reportPage{Code: chi.URLParam(r, "url")})
+}
+
+// ReportPage adds abuse report, that is sent by form, to moderation
+// queue.
+func (h *httpHandler) ReportPage(w http.ResponseWriter, r *http.Request) {
+	page := reportPage{Code: chi.URLParam(r, "url")}
+
+	user, _ := r.Context().Value(middleware.Key).(string)
+	err := h.h.ReportURL(&models.Report{
+		ShortURL: page.Code,
+		Reason:   r.PostFormValue("reason"),
+		Reporter: user,
+		IP:       h.clientIP(r),
+	})
+	if err != nil {
+		code := moderationStatus(err)
+		if code == http.StatusInternalServerError {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+
+		page.Error = err.Error()
+		if code != http.StatusBadRequest {
+			page.Error = http.StatusText(code)
+		}
+		h.writeReportPage(w, code, page)
+		return
+	}
+
+	page.Sent = true
+	h.writeReportPage(w, http.StatusCreated, page)
+}
+
+// ReportURL adds abuse report of short URL in json to moderation
+// queue.
+func (h *httpHandler) ReportURL(w http.ResponseWriter, r *http.Request) {
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	req := &reason{}
+	if err = s.Decode(b, req); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := r.Context().Value(middleware.Key).(string)
+	report := &models.Report{
+		ShortURL: chi.URLParam(r, "url"),
+		Reason:   req.Reason,
+		Reporter: user,
+		IP:       h.clientIP(r),
+	}
+	if err := h.h.ReportURL(report); err != nil {
+		writeModerationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// GetReports shows reports of moderation queue in json, reports are
+// filtered by "status" query parameter, if it is set.
+func (h *httpHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.h.GetReports(models.ReportStatus(r.URL.Query().Get("status")))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// DisableURL disables short URL with reason in json, that is shown
+// to visitors.
+func (h *httpHandler) DisableURL(w http.ResponseWriter, r *http.Request) {
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	req := &reason{}
+	if err = s.Decode(b, req); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.h.DisableURL(chi.URLParam(r, "url"), req.Reason, h.clientIP(r)); err != nil {
+		writeModerationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreURL enables short URL, that was disabled.
+func (h *httpHandler) RestoreURL(w http.ResponseWriter, r *http.Request) {
+	if err := h.h.RestoreURL(chi.URLParam(r, "url"), h.clientIP(r)); err != nil {
+		writeModerationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FlagURL flags short URL with reason in json, so visitors are warned
+// before redirect.
+func (h *httpHandler) FlagURL(w http.ResponseWriter, r *http.Request) {
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	req := &reason{}
+	if err = s.Decode(b, req); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.h.FlagURL(chi.URLParam(r, "url"), req.Reason, h.clientIP(r)); err != nil {
+		writeModerationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnflagURL removes flag of moderator from short URL.
+func (h *httpHandler) UnflagURL(w http.ResponseWriter, r *http.Request) {
+	if err := h.h.UnflagURL(chi.URLParam(r, "url"), h.clientIP(r)); err != nil {
+		writeModerationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAuditLog shows audit log of moderation in json, it is filtered
+// by "short_url" query parameter, if it is set.
+func (h *httpHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.h.GetAuditLog(r.URL.Query().Get("short_url"))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// moderationStatus returns response code for error of moderation.
+func moderationStatus(err error) int {
+	switch {
+	case errors.Is(err, shortener.ErrorInvalidReason):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrorNoLinkFound):
+		return http.StatusNotFound
+	case errors.Is(err, handlers.ErrorURLIsGone):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeModerationError writes error of moderation with its code.
+func writeModerationError(w http.ResponseWriter, err error) {
+	code := moderationStatus(err)
+	if code == http.StatusBadRequest {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	http.Error(w, http.StatusText(code), code)
+}
+
+// writeReportPage renders page of abuse report with code.
+func (h *httpHandler) writeReportPage(w http.ResponseWriter, code int, page reportPage) {
+	var b bytes.Buffer
+	if err := h.templates.report.Execute(&b, page); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	if _, err := w.Write(b.Bytes()); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeJSON writes v in json with code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	s, err := serializers.GetSerializer("json")
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := s.Encode(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(b); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
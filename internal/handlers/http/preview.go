@@ -0,0 +1,51 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+)
+
+// previewSuffix is appended to short URL to request preview page
+// instead of redirect.
+const previewSuffix = "+"
+
+// previewTemplate renders models.Expansion as a page, that shows
+// where short URL leads.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Title}}{{.Title}}{{else}}{{.ShortURL}}{{end}}</title>
+</head>
+<body>
+<h1>{{if .Title}}{{.Title}}{{else}}{{.ShortURL}}{{end}}</h1>
+{{if eq .State "active"}}<p>{{.ShortURL}} leads to</p>
+<p><a href="{{.URL}}" rel="noopener noreferrer nofollow">{{.URL}}</a></p>
+{{if .Flags}}<p>Warning: the destination was flagged as suspicious ({{range $i, $f := .Flags}}{{if $i}}, {{end}}{{$f}}{{end}}).</p>
+{{end}}<p><a href="/report/{{.Code}}">Report this link</a></p>
+{{else}}<p>{{.ShortURL}} is {{.State}}{{with .Reason}}: {{.}}{{end}}.</p>
+{{end}}{{with .CreatedAt}}<p>Created on {{.Format "2006-01-02"}}</p>
+{{end}}</body>
+</html>
+`))
+
+// preview is a data of previewTemplate.
+type preview struct {
+	*models.Expansion
+
+	// Code is short URL without base URL.
+	Code string
+}
+
+// previewCode returns short URL, when preview page is requested
+// either by suffix of short URL or by "preview" query parameter.
+func previewCode(r *http.Request, shortURL string) (string, bool) {
+	if strings.HasSuffix(shortURL, previewSuffix) {
+		return strings.TrimSuffix(shortURL, previewSuffix), true
+	}
+
+	return shortURL, r.URL.Query().Get("preview") == "1"
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+)
+
+// Names of template files, that override default pages.
+const (
+	previewFile      = "preview.html"
+	interstitialFile = "interstitial.html"
+	reportFile       = "report.html"
+)
+
+// templates are templates of html pages.
+type templates struct {
+	preview      *template.Template
+	interstitial *template.Template
+	report       *template.Template
+}
+
+// defaultTemplates returns built-in templates of html pages.
+func defaultTemplates() templates {
+	return templates{
+		preview:      previewTemplate,
+		interstitial: interstitialTemplate,
+		report:       reportTemplate,
+	}
+}
+
+// LoadTemplates overrides templates of html pages by files of dir,
+// files "preview.html", "interstitial.html" and "report.html" are
+// used, if they exist, other pages keep built-in templates.
+func (h *httpHandler) LoadTemplates(dir string) error {
+	t := defaultTemplates()
+
+	for name, dst := range map[string]**template.Template{
+		previewFile:      &t.preview,
+		interstitialFile: &t.interstitial,
+		reportFile:       &t.report,
+	} {
+		parsed, err := template.ParseFiles(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		*dst = parsed
+	}
+
+	h.templates = t
+	return nil
+}
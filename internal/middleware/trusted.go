@@ -1,25 +1,118 @@
 package middleware
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	"strings"
 )
 
-type TrustedNetworksOnlyMiddleware struct {
-	IPs map[string]struct{}
+// TrustedPolicy decides whether client belongs to trusted networks.
+// It is shared by HTTP middleware and gRPC interceptor.
+type TrustedPolicy struct {
+	// networks are trusted networks.
+	networks []*net.IPNet
+
+	// proxies are networks of proxies, whose X-Real-IP is trusted,
+	// address of client, that is passed by other hosts, is ignored.
+	proxies []*net.IPNet
 }
 
-func NewTrustedNetworksOnlyMiddleware(IPs []string) *TrustedNetworksOnlyMiddleware {
-	m := make(map[string]struct{})
-	for _, v := range IPs {
-		m[v] = struct{}{}
+// NewTrustedPolicy creates policy by trusted networks and networks of
+// proxies. Network is either an IP address or a CIDR, like
+// "192.168.1.0/24".
+func NewTrustedPolicy(networks []string, proxies []string) (*TrustedPolicy, error) {
+	n, err := parseNetworks(networks)
+	if err != nil {
+		return nil, err
 	}
-	return &TrustedNetworksOnlyMiddleware{IPs: m}
+
+	p, err := parseNetworks(proxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrustedPolicy{networks: n, proxies: p}, nil
+}
+
+// ClientIP returns IP address of client by address of peer, that sent
+// request, and address, that is passed by proxy in X-Real-IP. Address
+// of proxy is used only, if peer is a trusted proxy.
+func (p *TrustedPolicy) ClientIP(peer string, realIP string) string {
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if realIP != "" && contains(p.proxies, peer) {
+		return realIP
+	}
+
+	return peer
+}
+
+// Allowed checks whether IP address ip belongs to trusted networks.
+func (p *TrustedPolicy) Allowed(ip string) bool {
+	return contains(p.networks, ip)
+}
+
+// contains checks whether IP address ip belongs to one of networks.
+func contains(networks []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false
+	}
+
+	for _, n := range networks {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseNetworks parses IP addresses and CIDRs.
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	var result []*net.IPNet
+	for _, v := range networks {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted network %q", v)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted network %q: %w", v, err)
+		}
+		result = append(result, n)
+	}
+
+	return result, nil
+}
+
+// TrustedNetworksOnlyMiddleware forbids requests of clients, that
+// don't belong to trusted networks.
+type TrustedNetworksOnlyMiddleware struct {
+	policy *TrustedPolicy
+}
+
+func NewTrustedNetworksOnlyMiddleware(policy *TrustedPolicy) *TrustedNetworksOnlyMiddleware {
+	return &TrustedNetworksOnlyMiddleware{policy: policy}
 }
 
 func (t *TrustedNetworksOnlyMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		xRealIP := r.Header.Get("X-Real-IP")
-		if _, ok := t.IPs[xRealIP]; !ok {
+		if !t.policy.Allowed(t.policy.ClientIP(r.RemoteAddr, r.Header.Get("X-Real-IP"))) {
 			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 			return
 		}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedPolicy(t *testing.T) {
+	p, err := NewTrustedPolicy([]string{"192.168.1.1", "10.0.0.0/8", "::1"}, []string{"172.16.0.1"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		peer    string
+		realIP  string
+		allowed bool
+	}{
+		{name: "Test case #1", peer: "192.168.1.1:1234", allowed: true},
+		{name: "Test case #2", peer: "192.168.1.2:1234", allowed: false},
+		{name: "Test case #3", peer: "10.1.2.3:1234", allowed: true},
+		{name: "Test case #4", peer: "[::1]:1234", allowed: true},
+		{name: "Test case #5", peer: "172.16.0.1:1234", realIP: "10.0.0.5", allowed: true},
+		{name: "Test case #6", peer: "172.16.0.1:1234", realIP: "8.8.8.8", allowed: false},
+		{name: "Test case #7", peer: "8.8.8.8:1234", realIP: "10.0.0.5", allowed: false},
+		{name: "Test case #8", peer: "", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, p.Allowed(p.ClientIP(tt.peer, tt.realIP)))
+		})
+	}
+
+	_, err = NewTrustedPolicy([]string{"192.168.1"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewTrustedPolicy(nil, []string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestTrustedNetworksOnlyMiddleware(t *testing.T) {
+	p, err := NewTrustedPolicy([]string{"192.0.2.1"}, nil)
+	assert.NoError(t, err)
+
+	handler := NewTrustedNetworksOnlyMiddleware(p).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	request.RemoteAddr = "8.8.8.8:1234"
+	request.Header.Set("X-Real-IP", "192.0.2.1")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -1,6 +1,134 @@
 // Package models provides required structs for URL.
 package models
 
+import (
+	"net/url"
+	"time"
+)
+
+// QueryPolicy defines how query of a short URL visit is passed
+// to original URL.
+type QueryPolicy string
+
+const (
+	// QueryDrop drops query of a visit, it is used by default.
+	QueryDrop QueryPolicy = "drop"
+
+	// QueryKeep merges query of a visit into original URL, on
+	// conflict the value of original URL is kept.
+	QueryKeep QueryPolicy = "keep"
+
+	// QueryOverride merges query of a visit into original URL, on
+	// conflict the value of a visit replaces the value of original URL.
+	QueryOverride QueryPolicy = "override"
+
+	// QueryAppend merges query of a visit into original URL, on
+	// conflict both values are kept.
+	QueryAppend QueryPolicy = "append"
+)
+
+// DedupScope defines, which short URLs are searched for duplicates
+// of original URL, when short URL is created.
+type DedupScope string
+
+const (
+	// DedupGlobal returns existing short URL of any user.
+	DedupGlobal DedupScope = "global"
+
+	// DedupUser returns existing short URL of the same user, so each
+	// user owns their short URLs.
+	DedupUser DedupScope = "user"
+
+	// DedupNone creates new short URL for each request.
+	DedupNone DedupScope = "none"
+)
+
+// Platforms of visitors, that are detected by user agent.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+	PlatformOther   = "other"
+)
+
+// TargetingRule redirects visitors, that match platform and
+// language of the rule, to URL. Empty platform or language
+// matches any visitor.
+type TargetingRule struct {
+	// Platform is one of Platform constants.
+	Platform string `json:"platform,omitempty"`
+
+	// Language is a language tag, like "en" or "en-US", that
+	// visitor accepts.
+	Language string `json:"language,omitempty"`
+
+	// URL is a destination for matched visitors.
+	URL string `json:"url"`
+}
+
+// GeoRule redirects visitors from countries of the rule to URL.
+type GeoRule struct {
+	// Countries are ISO 3166-1 alpha-2 codes, like "KZ".
+	Countries []string `json:"countries"`
+
+	// URL is a destination for matched visitors.
+	URL string `json:"url"`
+}
+
+// Variant is one of destinations of short URL, that are rotated
+// by weight, it is used for A/B tests.
+type Variant struct {
+	// Name identifies variant in analytics and in visitor's cookie.
+	Name string `json:"name"`
+
+	// URL is a destination of variant.
+	URL string `json:"url"`
+
+	// Weight is a relative share of visits, that are served the variant.
+	Weight uint `json:"weight"`
+}
+
+// VariantStats is a variant with number of visits, that it was served.
+type VariantStats struct {
+	Variant
+
+	Clicks uint `json:"clicks"`
+}
+
+// Options are optional settings of short URL, that are set
+// by owner and are used on redirect.
+type Options struct {
+	// Title is a title of short URL, that is shown on preview page.
+	Title string `json:"title,omitempty"`
+
+	// QueryPolicy defines how query of a visit is passed to original URL.
+	QueryPolicy QueryPolicy `json:"query_policy,omitempty"`
+
+	// UTM is a set of UTM parameter templates, that are appended
+	// to original URL on redirect.
+	UTM map[string]string `json:"utm,omitempty"`
+
+	// Targeting are rules, that are evaluated in order, the first
+	// matched rule defines destination, if no rule is matched
+	// original URL is used.
+	Targeting []TargetingRule `json:"targeting,omitempty"`
+
+	// Geo are rules by country of visitor, that are evaluated in
+	// order, if no targeting rule is matched.
+	Geo []GeoRule `json:"geo,omitempty"`
+
+	// Variants are destinations, that are rotated by weight, if no
+	// targeting or geo rule is matched.
+	Variants []Variant `json:"variants,omitempty"`
+
+	// Flags are warnings about destinations, like "confusable",
+	// that are set by service, visitors of flagged short URL are
+	// warned before redirect.
+	Flags []string `json:"flags,omitempty"`
+}
+
 // URL is a struct that has original URL, short URL, and
 // owner of URL
 type URL struct {
@@ -12,6 +140,12 @@ type URL struct {
 
 	// ShortURL is short URL
 	ShortURL string `json:"short_url"`
+
+	// CanonicalURL is canonical form of original URL, that is
+	// used to find duplicates.
+	CanonicalURL string `json:"-"`
+
+	Options
 }
 
 // ShortURL is used for json response,
@@ -20,7 +154,147 @@ type ShortURL struct {
 	ShortURL string `json:"result"`
 }
 
+// LinkState is a state of short URL, that is reported on expansion.
+type LinkState string
+
+const (
+	// LinkActive is a state of short URL, that redirects visitors.
+	LinkActive LinkState = "active"
+
+	// LinkDeleted is a state of short URL, that was deleted by owner.
+	LinkDeleted LinkState = "deleted"
+
+	// LinkDisabled is a state of short URL, that was disabled by
+	// policy of service.
+	LinkDisabled LinkState = "disabled"
+)
+
+// Expansion describes where short URL leads, it is used to
+// preview short URL without redirect.
+type Expansion struct {
+	// ShortURL is short URL with base URL.
+	ShortURL string `json:"short_url"`
+
+	// URL is original URL, it is empty, if short URL is not active.
+	URL string `json:"url,omitempty"`
+
+	// Title is a title of short URL, that is set by owner.
+	Title string `json:"title,omitempty"`
+
+	// CreatedAt is a time of creation of short URL, it is nil,
+	// if storage doesn't know it.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// State is a state of short URL.
+	State LinkState `json:"state"`
+
+	// Flags are warnings about destinations of active short URL.
+	Flags []string `json:"flags,omitempty"`
+
+	// Reason is a reason, why short URL was disabled, that is shown
+	// to visitors.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReportStatus is a status of abuse report in moderation queue.
+type ReportStatus string
+
+const (
+	// ReportOpen is a status of report, that waits for moderator.
+	ReportOpen ReportStatus = "open"
+
+	// ReportResolved is a status of report, that was resolved by
+	// disabling, flagging or restoring reported short URL.
+	ReportResolved ReportStatus = "resolved"
+)
+
+// Report is an abuse report of short URL.
+type Report struct {
+	ID int64 `json:"id"`
+
+	// ShortURL is reported short URL without base URL.
+	ShortURL string `json:"short_url"`
+
+	// Reason describes why short URL is abusive.
+	Reason string `json:"reason"`
+
+	// Reporter is an identificator of user, that reported short URL.
+	Reporter string `json:"reporter,omitempty"`
+
+	// IP is an IP address of reporter.
+	IP string `json:"ip,omitempty"`
+
+	Status    ReportStatus `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// AuditAction is an action, that is recorded in audit log.
+type AuditAction string
+
+const (
+	// AuditReport is recorded, when short URL is reported.
+	AuditReport AuditAction = "report"
+
+	// AuditDisable is recorded, when moderator disables short URL.
+	AuditDisable AuditAction = "disable"
+
+	// AuditRestore is recorded, when moderator restores short URL.
+	AuditRestore AuditAction = "restore"
+
+	// AuditFlag is recorded, when moderator flags short URL.
+	AuditFlag AuditAction = "flag"
+
+	// AuditUnflag is recorded, when moderator removes flag of short URL.
+	AuditUnflag AuditAction = "unflag"
+)
+
+// AuditEntry is a record of audit log of moderation.
+type AuditEntry struct {
+	ID       int64       `json:"id"`
+	Action   AuditAction `json:"action"`
+	ShortURL string      `json:"short_url"`
+
+	// Actor is an IP address of moderator or identificator of
+	// reporter.
+	Actor string `json:"actor"`
+
+	// Reason is a reason of report or disabling.
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Stats struct {
 	URLs  uint `json:"urls"`
 	Users uint `json:"users"`
 }
+
+// Visit describes request of a visitor to short URL, that
+// is used to build destination of redirect.
+type Visit struct {
+	// Query is a query of short URL.
+	Query url.Values
+
+	// Referer is a page, that visitor came from.
+	Referer string
+
+	// UserAgent is a User-Agent header of visitor.
+	UserAgent string
+
+	// AcceptLanguage is an Accept-Language header of visitor.
+	AcceptLanguage string
+
+	// IP is an IP address of visitor.
+	IP string
+
+	// Country is ISO 3166-1 alpha-2 code of visitor's country, it
+	// is resolved by IP, when short URL has geo rules.
+	Country string
+
+	// Variant is a name of variant, that was served to visitor before.
+	// When destination is built, it is replaced by name of served
+	// variant, or by empty string, if no variant was served.
+	Variant string
+
+	// Time is a time of a visit.
+	Time time.Time
+}
@@ -0,0 +1,79 @@
+package qr
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheSize is a total size of images in bytes, that are kept
+// by cache by default.
+const DefaultCacheSize = 32 << 20
+
+// Cache keeps recently rendered images, the least recently used
+// images are evicted, when total size of images exceeds capacity.
+// Images larger than capacity aren't cached.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	size     int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// entry is an element of list of cache.
+type entry struct {
+	key   string
+	image []byte
+}
+
+// NewCache returns Cache, that keeps images of capacity bytes in total.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns image by key.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.ll.MoveToFront(e)
+	return e.Value.(*entry).image, true
+}
+
+// Put stores image by key.
+func (c *Cache) Put(key string, image []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+
+	if len(image) > c.capacity {
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&entry{key: key, image: image})
+	c.size += len(image)
+
+	for c.size > c.capacity {
+		c.remove(c.ll.Back())
+	}
+}
+
+// remove removes element e from cache.
+func (c *Cache) remove(e *list.Element) {
+	en := e.Value.(*entry)
+	c.ll.Remove(e)
+	delete(c.items, en.key)
+	c.size -= len(en.image)
+}
@@ -0,0 +1,197 @@
+// Package qr renders QR codes of short URLs as PNG or SVG images.
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Format is a format of rendered image.
+type Format string
+
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+)
+
+const (
+	// DefaultSize is a size of image in pixels, that is used,
+	// if size is not set.
+	DefaultSize = 256
+
+	// MinSize is a minimum size of image in pixels.
+	MinSize = 64
+
+	// MaxSize is a maximum size of image in pixels.
+	MaxSize = 2048
+
+	// DefaultLevel is an error correction level, that is used,
+	// if level is not set.
+	DefaultLevel = "M"
+
+	// labelHeight is a height of label under QR code of PNG image.
+	labelHeight = 20
+)
+
+var ErrorInvalidOptions = errors.New("invalid QR code options")
+
+// levels maps error correction levels to levels of encoder.
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options define how QR code is rendered.
+type Options struct {
+	// Format is either PNG or SVG, PNG is used by default.
+	Format Format
+
+	// Size is a width of image in pixels.
+	Size int
+
+	// Level is an error correction level, one of "L", "M", "Q" or "H".
+	Level string
+
+	// Label adds encoded content as text under QR code.
+	Label bool
+}
+
+// Normalize checks options and sets defaults of options, that
+// are not set.
+func (o *Options) Normalize() error {
+	switch o.Format {
+	case "":
+		o.Format = PNG
+	case PNG, SVG:
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrorInvalidOptions, o.Format)
+	}
+
+	if o.Size == 0 {
+		o.Size = DefaultSize
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("%w: size should be from %d to %d", ErrorInvalidOptions, MinSize, MaxSize)
+	}
+
+	if o.Level == "" {
+		o.Level = DefaultLevel
+	}
+	o.Level = strings.ToUpper(o.Level)
+	if _, ok := levels[o.Level]; !ok {
+		return fmt.Errorf("%w: unknown error correction level %q", ErrorInvalidOptions, o.Level)
+	}
+
+	return nil
+}
+
+// Key returns key of rendered image of content, that is used for caching.
+func (o Options) Key(content string) string {
+	return fmt.Sprintf("%s|%d|%s|%t|%s", o.Format, o.Size, o.Level, o.Label, content)
+}
+
+// Render encodes content as QR code and renders it as image.
+func Render(content string, o Options) ([]byte, error) {
+	if err := o.Normalize(); err != nil {
+		return nil, err
+	}
+
+	q, err := qrcode.New(content, levels[o.Level])
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Format == SVG {
+		return renderSVG(q.Bitmap(), content, o), nil
+	}
+
+	return renderPNG(q, content, o)
+}
+
+// renderPNG renders QR code as PNG image.
+func renderPNG(q *qrcode.QRCode, content string, o Options) ([]byte, error) {
+	var img image.Image = q.Image(o.Size)
+
+	if o.Label {
+		img = addLabel(img, content)
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// addLabel draws text under image, the image is widened, if text
+// doesn't fit.
+func addLabel(img image.Image, text string) image.Image {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil() + labelHeight
+
+	bounds := img.Bounds()
+	if width < bounds.Dx() {
+		width = bounds.Dx()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, bounds.Dy()+labelHeight))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds.Add(image.Pt((width-bounds.Dx())/2, 0)), img, bounds.Min, draw.Src)
+
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+	d.Dot = fixed.P((width-d.MeasureString(text).Ceil())/2, bounds.Dy()+labelHeight/2)
+	d.DrawString(text)
+
+	return dst
+}
+
+// renderSVG renders bitmap of QR code as SVG image, each dark module
+// is a square of one unit.
+func renderSVG(bitmap [][]bool, content string, o Options) []byte {
+	n := len(bitmap)
+
+	height := n
+	if o.Label {
+		height += 3
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		o.Size, o.Size*height/n, n, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, height)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if o.Label {
+		fmt.Fprintf(&b, `<text x="%g" y="%d" font-family="monospace" font-size="2" text-anchor="middle">%s</text>`,
+			float64(n)/2, n+1, html.EscapeString(content))
+	}
+	b.WriteString("</svg>")
+
+	return b.Bytes()
+}
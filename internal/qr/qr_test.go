@@ -0,0 +1,134 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    Options
+		wantErr bool
+	}{
+		{
+			name:    "Test case #1",
+			options: Options{},
+			want:    Options{Format: PNG, Size: DefaultSize, Level: DefaultLevel},
+		},
+		{
+			name:    "Test case #2",
+			options: Options{Format: SVG, Size: 512, Level: "h", Label: true},
+			want:    Options{Format: SVG, Size: 512, Level: "H", Label: true},
+		},
+		{
+			name:    "Test case #3",
+			options: Options{Format: "gif"},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #4",
+			options: Options{Size: MaxSize + 1},
+			wantErr: true,
+		},
+		{
+			name:    "Test case #5",
+			options: Options{Level: "X"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Normalize()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrorInvalidOptions)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.options)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	content := "http://localhost:8080/asdf"
+
+	b, err := Render(content, Options{Size: 128})
+	assert.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, 128, img.Bounds().Dx())
+	assert.Equal(t, 128, img.Bounds().Dy())
+
+	b, err = Render(content, Options{Size: 128, Label: true})
+	assert.NoError(t, err)
+
+	img, err = png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Greater(t, img.Bounds().Dx(), 128)
+	assert.Equal(t, 128+labelHeight, img.Bounds().Dy())
+
+	b, err = Render(content, Options{Format: SVG, Size: 128, Label: true})
+	assert.NoError(t, err)
+
+	svg := string(b)
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="128"`))
+	assert.Contains(t, svg, ">"+content+"</text>")
+}
+
+func TestCache(t *testing.T) {
+	c := NewCache(2)
+
+	c.Put("a", []byte("a"))
+	c.Put("b", []byte("b"))
+
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Put("c", []byte("c"))
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	got, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), got)
+
+	got, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("c"), got)
+}
+
+func TestCache_Size(t *testing.T) {
+	c := NewCache(10)
+
+	c.Put("a", []byte("aaaa"))
+	c.Put("b", []byte("bbbb"))
+	c.Put("c", []byte("cccc"))
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	c.Put("big", make([]byte, 11))
+
+	_, ok = c.Get("big")
+	assert.False(t, ok)
+
+	c.Put("b", []byte("bbbbbbbb"))
+
+	_, ok = c.Get("c")
+	assert.False(t, ok)
+
+	got, ok := c.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bbbbbbbb"), got)
+}
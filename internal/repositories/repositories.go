@@ -2,7 +2,11 @@
 // for storages to use and implement.
 package repositories
 
-import "github.com/Fe4p3b/url-shortener/internal/models"
+import (
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/internal/models"
+)
 
 // ShortenerRepository provides functionality to find,
 // store and delete from storage.
@@ -25,6 +29,11 @@ type ShortenerRepository interface {
 	// certain base URL, like localhost:8080.
 	GetUserURLs(string, string) ([]URL, error)
 
+	// GetUserURLsPage returns at most limit URLs of user, that aren't
+	// deleted, ordered by short URL, starting after short URL after.
+	// Short URLs of the page are returned without base URL.
+	GetUserURLsPage(user string, after string, limit int) ([]URL, error)
+
 	// Flush flushes buffer, that is used for bulk URL
 	// addition
 	Flush() error
@@ -36,6 +45,59 @@ type ShortenerRepository interface {
 	// Ping tests connection with storage or returns error.
 	Ping() error
 
+	// GetOptions returns options of user's short URL.
+	GetOptions(shortURL string, user string) (*models.Options, error)
+
+	// UpdateOptions replaces options of user's short URL by options,
+	// that update sets to URL. Update is called with the current state
+	// of short URL, that can't be changed by other updates of options
+	// or flags, until options are replaced. Options aren't replaced, if
+	// update returns error. Update must not call methods of repository.
+	UpdateOptions(shortURL string, user string, update func(u *URL) error) error
+
+	// AddClick counts visit of short URL, that was served variant.
+	AddClick(shortURL string, variant string) error
+
+	// GetClicks returns number of visits of short URL by variant.
+	GetClicks(shortURL string) (map[string]uint, error)
+
+	// GetEnabledURLs returns short URLs, that are neither deleted nor
+	// disabled, with original URLs and options.
+	GetEnabledURLs() ([]URL, error)
+
+	// DisableURLs disables short URLs with reason.
+	DisableURLs(reason string, shortURLs []string) error
+
+	// AddFlag adds flag to flags of short URL regardless of owner,
+	// other flags are kept. Flag is added once.
+	AddFlag(shortURL string, flag string) error
+
+	// RemoveFlag removes flag from flags of short URL regardless of
+	// owner, other flags are kept.
+	RemoveFlag(shortURL string, flag string) error
+
+	// EnableURLs enables disabled short URLs.
+	EnableURLs(shortURLs []string) error
+
+	// AddReport adds abuse report to moderation queue, it sets
+	// identificator and creation time of report.
+	AddReport(r *models.Report) error
+
+	// GetReports returns reports with status, or all reports, if
+	// status is empty, in order of creation.
+	GetReports(status models.ReportStatus) ([]models.Report, error)
+
+	// ResolveReports resolves open reports of short URL.
+	ResolveReports(shortURL string) error
+
+	// AddAuditEntry appends entry to audit log, it sets identificator
+	// and creation time of entry.
+	AddAuditEntry(e *models.AuditEntry) error
+
+	// GetAuditLog returns audit log of short URL, or of all short
+	// URLs, if short URL is empty, in order of creation.
+	GetAuditLog(shortURL string) ([]models.AuditEntry, error)
+
 	GetStats() (*models.Stats, error)
 }
 
@@ -50,7 +112,18 @@ type AuthRepository interface {
 type URL struct {
 	CorrelationID string `json:"correlation_id,omitempty"`
 	URL           string `json:"original_url,omitempty"`
+	CanonicalURL  string `json:"-"`
 	ShortURL      string `json:"short_url,omitempty"`
 	UserID        string `json:"-"`
 	IsDeleted     bool   `json:"-"`
+
+	// DisabledReason is a reason, why short URL was disabled by
+	// policy of service, it is empty for enabled short URL.
+	DisabledReason string `json:"-"`
+
+	// CreatedAt is a time of creation of short URL, it is zero,
+	// if storage doesn't know it.
+	CreatedAt time.Time `json:"-"`
+
+	models.Options
 }
@@ -3,9 +3,12 @@ package file
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
@@ -14,12 +17,81 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	// optionsSuffix is appended to path of file storage to get path
+	// of a file, where owners and options of short URLs are stored.
+	optionsSuffix = ".options"
+
+	// moderationSuffix is appended to path of file storage to get
+	// path of a file, where disabled short URLs, abuse reports and
+	// audit log are stored.
+	moderationSuffix = ".moderation"
+
+	// clicksSuffix is appended to path of file storage to get path
+	// of a file, where visits of short URLs by variant are stored.
+	clicksSuffix = ".clicks"
+)
+
+// Actions of moderation records.
+const (
+	moderationDisable = "disable"
+	moderationEnable  = "enable"
+	moderationReport  = "report"
+	moderationResolve = "resolve"
+	moderationAudit   = "audit"
+)
+
 // file implements file storage.
 type file struct {
 	path string
 	file *os.File
 	rw   *bufio.ReadWriter
 	m    *memory.Memory
+
+	// mu guards files, that are opened on first write.
+	mu sync.Mutex
+
+	// optionsMu serializes changes of options, so records of options
+	// file are appended in order of changes.
+	optionsMu sync.Mutex
+
+	// options is a file, where owners and options of short URLs are
+	// appended as json lines, it is opened on first write.
+	options *os.File
+
+	// moderation is a file, where changes of moderation state are
+	// appended as json lines, it is opened on first write.
+	moderation *os.File
+
+	// clicks is a file, where visits of short URLs are appended as
+	// json lines, it is opened on first write.
+	clicks *os.File
+}
+
+// optionsRecord is a line of options file, the latest record
+// of short URL overrides previous ones.
+type optionsRecord struct {
+	ShortURL  string         `json:"short_url"`
+	UserID    string         `json:"user_id,omitempty"`
+	Options   models.Options `json:"options"`
+	CreatedAt *time.Time     `json:"created_at,omitempty"`
+}
+
+// clickRecord is a line of clicks file, it counts a single visit
+// of short URL, that was served variant.
+type clickRecord struct {
+	ShortURL string `json:"short_url"`
+	Variant  string `json:"variant"`
+}
+
+// moderationRecord is a line of moderation file, records are replayed
+// in order on start.
+type moderationRecord struct {
+	Action    string             `json:"action"`
+	ShortURLs []string           `json:"short_urls,omitempty"`
+	Reason    string             `json:"reason,omitempty"`
+	Report    *models.Report     `json:"report,omitempty"`
+	Entry     *models.AuditEntry `json:"entry,omitempty"`
 }
 
 var _ repositories.ShortenerRepository = &file{}
@@ -45,6 +117,18 @@ func NewFile(path string) (*file, error) {
 			return nil, err
 		}
 
+		if err = s.loadOptions(); err != nil {
+			return nil, err
+		}
+
+		if err = s.loadModeration(); err != nil {
+			return nil, err
+		}
+
+		if err = s.loadClicks(); err != nil {
+			return nil, err
+		}
+
 		return s, nil
 	}
 
@@ -83,11 +167,294 @@ func (f *file) Save(url *models.URL) error {
 		return err
 	}
 
-	return f.rw.Writer.Flush()
+	if err := f.rw.Writer.Flush(); err != nil {
+		return err
+	}
+
+	return f.saveOptions(url.ShortURL, url.UserID, url.Options)
+}
+
+// GetOptions implements repositories.ShortenerRepository GetOptions method.
+func (f *file) GetOptions(shortURL string, user string) (*models.Options, error) {
+	return f.m.GetOptions(shortURL, user)
+}
+
+// UpdateOptions implements repositories.ShortenerRepository UpdateOptions method.
+func (f *file) UpdateOptions(shortURL string, user string, update func(u *repositories.URL) error) error {
+	f.optionsMu.Lock()
+	defer f.optionsMu.Unlock()
+
+	var o models.Options
+	err := f.m.UpdateOptions(shortURL, user, func(u *repositories.URL) error {
+		if err := update(u); err != nil {
+			return err
+		}
+
+		o = u.Options
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return f.saveOptions(shortURL, user, o)
+}
+
+// AddClick implements repositories.ShortenerRepository AddClick method.
+func (f *file) AddClick(shortURL string, variant string) error {
+	if err := f.m.AddClick(shortURL, variant); err != nil {
+		return err
+	}
+
+	return f.appendRecord(&f.clicks, clicksSuffix, clickRecord{ShortURL: shortURL, Variant: variant})
+}
+
+// GetClicks implements repositories.ShortenerRepository GetClicks method.
+func (f *file) GetClicks(shortURL string) (map[string]uint, error) {
+	return f.m.GetClicks(shortURL)
+}
+
+// GetEnabledURLs implements repositories.ShortenerRepository GetEnabledURLs method.
+func (f *file) GetEnabledURLs() ([]repositories.URL, error) {
+	return f.m.GetEnabledURLs()
+}
+
+// DisableURLs implements repositories.ShortenerRepository DisableURLs method.
+func (f *file) DisableURLs(reason string, shortURLs []string) error {
+	if err := f.m.DisableURLs(reason, shortURLs); err != nil {
+		return err
+	}
+
+	return f.saveModeration(moderationRecord{Action: moderationDisable, ShortURLs: shortURLs, Reason: reason})
+}
+
+// AddFlag implements repositories.ShortenerRepository AddFlag method.
+func (f *file) AddFlag(shortURL string, flag string) error {
+	f.optionsMu.Lock()
+	defer f.optionsMu.Unlock()
+
+	if err := f.m.AddFlag(shortURL, flag); err != nil {
+		return err
+	}
+
+	return f.saveFlags(shortURL)
+}
+
+// RemoveFlag implements repositories.ShortenerRepository RemoveFlag method.
+func (f *file) RemoveFlag(shortURL string, flag string) error {
+	f.optionsMu.Lock()
+	defer f.optionsMu.Unlock()
+
+	if err := f.m.RemoveFlag(shortURL, flag); err != nil {
+		return err
+	}
+
+	return f.saveFlags(shortURL)
+}
+
+// saveFlags appends options of short URL with changed flags to options
+// file, optionsMu should be held.
+func (f *file) saveFlags(shortURL string) error {
+	f.m.RLock()
+	user, o := f.m.U[shortURL], f.m.O[shortURL]
+	f.m.RUnlock()
+
+	return f.saveOptions(shortURL, user, o)
+}
+
+// EnableURLs implements repositories.ShortenerRepository EnableURLs method.
+func (f *file) EnableURLs(shortURLs []string) error {
+	if err := f.m.EnableURLs(shortURLs); err != nil {
+		return err
+	}
+
+	return f.saveModeration(moderationRecord{Action: moderationEnable, ShortURLs: shortURLs})
+}
+
+// AddReport implements repositories.ShortenerRepository AddReport method.
+func (f *file) AddReport(r *models.Report) error {
+	if err := f.m.AddReport(r); err != nil {
+		return err
+	}
+
+	return f.saveModeration(moderationRecord{Action: moderationReport, Report: r})
+}
+
+// GetReports implements repositories.ShortenerRepository GetReports method.
+func (f *file) GetReports(status models.ReportStatus) ([]models.Report, error) {
+	return f.m.GetReports(status)
+}
+
+// ResolveReports implements repositories.ShortenerRepository ResolveReports method.
+func (f *file) ResolveReports(shortURL string) error {
+	if err := f.m.ResolveReports(shortURL); err != nil {
+		return err
+	}
+
+	return f.saveModeration(moderationRecord{Action: moderationResolve, ShortURLs: []string{shortURL}})
+}
+
+// AddAuditEntry implements repositories.ShortenerRepository AddAuditEntry method.
+func (f *file) AddAuditEntry(e *models.AuditEntry) error {
+	if err := f.m.AddAuditEntry(e); err != nil {
+		return err
+	}
+
+	return f.saveModeration(moderationRecord{Action: moderationAudit, Entry: e})
+}
+
+// GetAuditLog implements repositories.ShortenerRepository GetAuditLog method.
+func (f *file) GetAuditLog(shortURL string) ([]models.AuditEntry, error) {
+	return f.m.GetAuditLog(shortURL)
+}
+
+// loadOptions reads owners and options of short URLs from options
+// file, if it exists.
+func (f *file) loadOptions() error {
+	return f.readRecords(optionsSuffix, func(data []byte) error {
+		var r optionsRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		var createdAt time.Time
+		if r.CreatedAt != nil {
+			createdAt = *r.CreatedAt
+		}
+
+		f.m.Restore(r.ShortURL, r.UserID, r.Options, createdAt)
+		return nil
+	})
+}
+
+// saveOptions appends owner, options and creation time of short URL
+// to options file.
+func (f *file) saveOptions(shortURL string, user string, o models.Options) error {
+	r := optionsRecord{ShortURL: shortURL, UserID: user, Options: o}
+	if u, err := f.m.Find(shortURL); err == nil && !u.CreatedAt.IsZero() {
+		r.CreatedAt = &u.CreatedAt
+	}
+
+	return f.appendRecord(&f.options, optionsSuffix, r)
+}
+
+// loadModeration replays changes of moderation state from moderation
+// file, if it exists. Reports and audit entries keep identificators
+// and times, that were assigned on write.
+func (f *file) loadModeration() error {
+	return f.readRecords(moderationSuffix, func(data []byte) error {
+		var r moderationRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		switch r.Action {
+		case moderationDisable:
+			return f.m.DisableURLs(r.Reason, r.ShortURLs)
+		case moderationEnable:
+			return f.m.EnableURLs(r.ShortURLs)
+		case moderationResolve:
+			for _, u := range r.ShortURLs {
+				if err := f.m.ResolveReports(u); err != nil {
+					return err
+				}
+			}
+		case moderationReport:
+			if r.Report != nil {
+				f.m.Lock()
+				f.m.R = append(f.m.R, *r.Report)
+				f.m.Unlock()
+			}
+		case moderationAudit:
+			if r.Entry != nil {
+				f.m.Lock()
+				f.m.A = append(f.m.A, *r.Entry)
+				f.m.Unlock()
+			}
+		}
+
+		return nil
+	})
+}
+
+// saveModeration appends change of moderation state to moderation file.
+func (f *file) saveModeration(r moderationRecord) error {
+	return f.appendRecord(&f.moderation, moderationSuffix, r)
+}
+
+// loadClicks counts visits of short URLs from clicks file, if it
+// exists.
+func (f *file) loadClicks() error {
+	return f.readRecords(clicksSuffix, func(data []byte) error {
+		var r clickRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		return f.m.AddClick(r.ShortURL, r.Variant)
+	})
+}
+
+// readRecords calls fn with every line of file, whose path is path of
+// storage with suffix, if it exists.
+func (f *file) readRecords(suffix string, fn func(data []byte) error) error {
+	r, err := os.Open(f.path + suffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// appendRecord appends record as json line to w, w is opened on first
+// write at path of storage with suffix.
+func (f *file) appendRecord(w **os.File, suffix string, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if *w == nil {
+		file, err := os.OpenFile(f.path+suffix, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0777)
+		if err != nil {
+			return err
+		}
+		*w = file
+	}
+
+	_, err = (*w).Write(append(data, '\n'))
+	return err
 }
 
 // Close closes file.
 func (f *file) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, w := range []*os.File{f.options, f.moderation, f.clicks} {
+		if w == nil {
+			continue
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+
 	return f.file.Close()
 }
 
@@ -96,6 +463,11 @@ func (f *file) GetUserURLs(user string, baseURL string) ([]repositories.URL, err
 	return nil, storage.ErrorMethodIsNotImplemented
 }
 
+// GetUserURLsPage implements repositories.ShortenerRepository GetUserURLsPage method.
+func (f *file) GetUserURLsPage(user string, after string, limit int) ([]repositories.URL, error) {
+	return f.m.GetUserURLsPage(user, after, limit)
+}
+
 // Ping implements repositories.ShortenerRepository Ping method.
 func (f *file) Ping() error {
 	if _, err := os.Stat(f.path); os.IsNotExist(err) {
@@ -1,13 +1,17 @@
 package file
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
 	"github.com/Fe4p3b/url-shortener/internal/storage"
 	"github.com/Fe4p3b/url-shortener/internal/storage/memory"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_file_Find(t *testing.T) {
@@ -130,3 +134,98 @@ func TestMemory_FlushToDelete(t *testing.T) {
 	err := f.FlushToDelete()
 	assert.Error(t, storage.ErrorMethodIsNotImplemented, err)
 }
+
+func Test_file_Moderation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage")
+
+	f, err := NewFile(path)
+	require.NoError(t, err)
+	for _, u := range []string{"asdf", "qwer"} {
+		require.NoError(t, f.Save(&models.URL{ShortURL: u, URL: "http://yandex.ru/" + u, UserID: "user"}))
+	}
+	require.NoError(t, f.DisableURLs("phishing", []string{"asdf", "qwer"}))
+	require.NoError(t, f.EnableURLs([]string{"qwer"}))
+	require.NoError(t, f.AddFlag("qwer", "moderator"))
+	require.NoError(t, f.AddReport(&models.Report{ShortURL: "asdf", Reason: "phishing", IP: "192.0.2.1", Status: models.ReportOpen}))
+	require.NoError(t, f.AddReport(&models.Report{ShortURL: "qwer", Reason: "spam", Status: models.ReportOpen}))
+	require.NoError(t, f.ResolveReports("qwer"))
+	require.NoError(t, f.AddAuditEntry(&models.AuditEntry{Action: models.AuditDisable, ShortURL: "asdf", Actor: "192.0.2.1", Reason: "phishing"}))
+	reports, err := f.GetReports("")
+	require.NoError(t, err)
+	audit, err := f.GetAuditLog("")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = NewFile(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	tests := []struct {
+		name     string
+		shortURL string
+		disabled string
+		flags    []string
+	}{
+		{name: "Test case #1", shortURL: "asdf", disabled: "phishing"},
+		{name: "Test case #2", shortURL: "qwer", flags: []string{"moderator"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := f.Find(tt.shortURL)
+			require.NoError(t, err)
+			assert.Equal(t, tt.disabled, u.DisabledReason)
+			assert.Equal(t, tt.flags, u.Options.Flags)
+		})
+	}
+
+	got, err := f.GetReports("")
+	require.NoError(t, err)
+	assertEqualJSON(t, reports, got)
+	assert.Equal(t, models.ReportResolved, got[1].Status)
+
+	entries, err := f.GetAuditLog("asdf")
+	require.NoError(t, err)
+	assertEqualJSON(t, audit, entries)
+}
+
+func Test_file_Clicks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage")
+
+	f, err := NewFile(path)
+	require.NoError(t, err)
+	require.NoError(t, f.Save(&models.URL{ShortURL: "asdf", URL: "http://yandex.ru", UserID: "user"}))
+	for _, v := range []string{"a", "b", "a"} {
+		require.NoError(t, f.AddClick("asdf", v))
+	}
+	require.NoError(t, f.Close())
+
+	f, err = NewFile(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	tests := []struct {
+		name     string
+		shortURL string
+		want     map[string]uint
+	}{
+		{name: "Test case #1", shortURL: "asdf", want: map[string]uint{"a": 2, "b": 1}},
+		{name: "Test case #2", shortURL: "qwer", want: map[string]uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clicks, err := f.GetClicks(tt.shortURL)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, clicks)
+		})
+	}
+}
+
+// assertEqualJSON asserts, that expected and actual are encoded in the
+// same json, so times are compared without monotonic clock.
+func assertEqualJSON(t *testing.T, expected interface{}, actual interface{}) {
+	e, err := json.Marshal(expected)
+	require.NoError(t, err)
+	a, err := json.Marshal(actual)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(e), string(a))
+}
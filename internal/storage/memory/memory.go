@@ -2,7 +2,9 @@
 package memory
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
@@ -15,17 +17,48 @@ var _ repositories.AuthRepository = &Memory{}
 // Memory is in-memory storage.
 type Memory struct {
 	sync.RWMutex
+
+	// S maps short URL to original URL.
 	S map[string]string
+
+	// O maps short URL to its options.
+	O map[string]models.Options
+
+	// U maps short URL to its owner.
+	U map[string]string
+
+	// C maps short URL to number of visits by variant.
+	C map[string]map[string]uint
+
+	// T maps short URL to time of its creation.
+	T map[string]time.Time
+
+	// D maps disabled short URL to reason of disabling.
+	D map[string]string
+
+	// R is a moderation queue of abuse reports.
+	R []models.Report
+
+	// A is an audit log of moderation.
+	A []models.AuditEntry
 }
 
 func NewMemory(s map[string]string) *Memory {
 	return &Memory{
 		S: s,
+		O: make(map[string]models.Options),
+		U: make(map[string]string),
+		C: make(map[string]map[string]uint),
+		T: make(map[string]time.Time),
+		D: make(map[string]string),
 	}
 }
 
 // Find implements repositories.ShortenerRepository Find method.
 func (m *Memory) Find(url string) (u *repositories.URL, err error) {
+	m.RLock()
+	defer m.RUnlock()
+
 	v, ok := m.S[url]
 
 	if !ok {
@@ -34,6 +67,9 @@ func (m *Memory) Find(url string) (u *repositories.URL, err error) {
 	}
 	u = &repositories.URL{}
 	u.URL = v
+	u.Options = m.O[url]
+	u.CreatedAt = m.T[url]
+	u.DisabledReason = m.D[url]
 	return
 }
 
@@ -45,15 +81,305 @@ func (m *Memory) Save(url *models.URL) error {
 
 	m.Lock()
 	m.S[url.ShortURL] = url.URL
+	m.restore(url.ShortURL, url.UserID, url.Options, time.Now())
 	m.Unlock()
 	return nil
 }
 
+// Restore stores owner, options and creation time of short URL without
+// any checks, it is used by persistent storages to load their state.
+// Zero creation time keeps the stored one.
+func (m *Memory) Restore(shortURL string, user string, o models.Options, createdAt time.Time) {
+	m.Lock()
+	m.restore(shortURL, user, o, createdAt)
+	m.Unlock()
+}
+
+// restore stores owner, options and creation time of short URL, the
+// caller should hold the lock.
+func (m *Memory) restore(shortURL string, user string, o models.Options, createdAt time.Time) {
+	if m.O == nil {
+		m.O = make(map[string]models.Options)
+	}
+	m.O[shortURL] = o
+
+	if !createdAt.IsZero() {
+		if m.T == nil {
+			m.T = make(map[string]time.Time)
+		}
+		m.T[shortURL] = createdAt
+	}
+
+	if user == "" {
+		return
+	}
+
+	if m.U == nil {
+		m.U = make(map[string]string)
+	}
+	m.U[shortURL] = user
+}
+
+// GetOptions implements repositories.ShortenerRepository GetOptions method.
+func (m *Memory) GetOptions(shortURL string, user string) (*models.Options, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if owner, ok := m.U[shortURL]; !ok || owner != user {
+		return nil, storage.ErrorNoLinkFound
+	}
+
+	o := m.O[shortURL]
+	return &o, nil
+}
+
+// UpdateOptions implements repositories.ShortenerRepository UpdateOptions method.
+// Lock is held, while update is called.
+func (m *Memory) UpdateOptions(shortURL string, user string, update func(u *repositories.URL) error) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if owner, ok := m.U[shortURL]; !ok || owner != user {
+		return storage.ErrorNoLinkFound
+	}
+
+	u := &repositories.URL{
+		URL:      m.S[shortURL],
+		ShortURL: shortURL,
+		UserID:   user,
+		Options:  m.O[shortURL],
+	}
+	if err := update(u); err != nil {
+		return err
+	}
+
+	m.O[shortURL] = u.Options
+	return nil
+}
+
 // GetUserURLs implements repositories.ShortenerRepository GetUserURLs method.
 func (m *Memory) GetUserURLs(user string, baseURL string) ([]repositories.URL, error) {
 	return nil, storage.ErrorMethodIsNotImplemented
 }
 
+// GetUserURLsPage implements repositories.ShortenerRepository GetUserURLsPage method.
+func (m *Memory) GetUserURLsPage(user string, after string, limit int) ([]repositories.URL, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var keys []string
+	for shortURL, owner := range m.U {
+		if owner == user && shortURL > after {
+			keys = append(keys, shortURL)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	URLs := make([]repositories.URL, 0, len(keys))
+	for _, shortURL := range keys {
+		URLs = append(URLs, repositories.URL{
+			ShortURL:  shortURL,
+			URL:       m.S[shortURL],
+			UserID:    user,
+			CreatedAt: m.T[shortURL],
+			Options:   m.O[shortURL],
+		})
+	}
+
+	return URLs, nil
+}
+
+// AddClick implements repositories.ShortenerRepository AddClick method.
+func (m *Memory) AddClick(shortURL string, variant string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.C == nil {
+		m.C = make(map[string]map[string]uint)
+	}
+
+	if m.C[shortURL] == nil {
+		m.C[shortURL] = make(map[string]uint)
+	}
+	m.C[shortURL][variant]++
+
+	return nil
+}
+
+// GetClicks implements repositories.ShortenerRepository GetClicks method.
+func (m *Memory) GetClicks(shortURL string) (map[string]uint, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	clicks := make(map[string]uint, len(m.C[shortURL]))
+	for k, v := range m.C[shortURL] {
+		clicks[k] = v
+	}
+
+	return clicks, nil
+}
+
+// GetEnabledURLs implements repositories.ShortenerRepository GetEnabledURLs method.
+func (m *Memory) GetEnabledURLs() ([]repositories.URL, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	URLs := make([]repositories.URL, 0, len(m.S))
+	for k, v := range m.S {
+		if _, ok := m.D[k]; ok {
+			continue
+		}
+
+		URLs = append(URLs, repositories.URL{ShortURL: k, URL: v, UserID: m.U[k], Options: m.O[k]})
+	}
+
+	return URLs, nil
+}
+
+// DisableURLs implements repositories.ShortenerRepository DisableURLs method.
+func (m *Memory) DisableURLs(reason string, shortURLs []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.D == nil {
+		m.D = make(map[string]string)
+	}
+
+	for _, u := range shortURLs {
+		if _, ok := m.S[u]; ok {
+			m.D[u] = reason
+		}
+	}
+
+	return nil
+}
+
+// AddFlag implements repositories.ShortenerRepository AddFlag method.
+func (m *Memory) AddFlag(shortURL string, flag string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.S[shortURL]; !ok {
+		return storage.ErrorNoLinkFound
+	}
+
+	o := m.O[shortURL]
+	for _, f := range o.Flags {
+		if f == flag {
+			return nil
+		}
+	}
+
+	o.Flags = append(append([]string(nil), o.Flags...), flag)
+	m.O[shortURL] = o
+	return nil
+}
+
+// RemoveFlag implements repositories.ShortenerRepository RemoveFlag method.
+func (m *Memory) RemoveFlag(shortURL string, flag string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.S[shortURL]; !ok {
+		return storage.ErrorNoLinkFound
+	}
+
+	o := m.O[shortURL]
+	var flags []string
+	for _, f := range o.Flags {
+		if f != flag {
+			flags = append(flags, f)
+		}
+	}
+
+	o.Flags = flags
+	m.O[shortURL] = o
+	return nil
+}
+
+// EnableURLs implements repositories.ShortenerRepository EnableURLs method.
+func (m *Memory) EnableURLs(shortURLs []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, u := range shortURLs {
+		delete(m.D, u)
+	}
+
+	return nil
+}
+
+// AddReport implements repositories.ShortenerRepository AddReport method.
+func (m *Memory) AddReport(r *models.Report) error {
+	m.Lock()
+	defer m.Unlock()
+
+	r.ID = int64(len(m.R) + 1)
+	r.CreatedAt = time.Now()
+	m.R = append(m.R, *r)
+
+	return nil
+}
+
+// GetReports implements repositories.ShortenerRepository GetReports method.
+func (m *Memory) GetReports(status models.ReportStatus) ([]models.Report, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	reports := []models.Report{}
+	for _, r := range m.R {
+		if status == "" || r.Status == status {
+			reports = append(reports, r)
+		}
+	}
+
+	return reports, nil
+}
+
+// ResolveReports implements repositories.ShortenerRepository ResolveReports method.
+func (m *Memory) ResolveReports(shortURL string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for i := range m.R {
+		if m.R[i].ShortURL == shortURL && m.R[i].Status == models.ReportOpen {
+			m.R[i].Status = models.ReportResolved
+		}
+	}
+
+	return nil
+}
+
+// AddAuditEntry implements repositories.ShortenerRepository AddAuditEntry method.
+func (m *Memory) AddAuditEntry(e *models.AuditEntry) error {
+	m.Lock()
+	defer m.Unlock()
+
+	e.ID = int64(len(m.A) + 1)
+	e.CreatedAt = time.Now()
+	m.A = append(m.A, *e)
+
+	return nil
+}
+
+// GetAuditLog implements repositories.ShortenerRepository GetAuditLog method.
+func (m *Memory) GetAuditLog(shortURL string) ([]models.AuditEntry, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	entries := []models.AuditEntry{}
+	for _, e := range m.A {
+		if shortURL == "" || e.ShortURL == shortURL {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
 // Ping implements repositories.ShortenerRepository Ping method.
 func (m *Memory) Ping() error {
 	return nil
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/Fe4p3b/url-shortener/internal/models"
@@ -146,3 +147,82 @@ func TestMemory_FlushToDelete(t *testing.T) {
 	err := s.FlushToDelete()
 	assert.Error(t, storage.ErrorMethodIsNotImplemented, err)
 }
+
+func TestMemory_UpdateOptions(t *testing.T) {
+	s := NewMemory(map[string]string{})
+	err := s.Save(&models.URL{URL: "https://yandex.ru", ShortURL: "asdf", UserID: "user"})
+	assert.NoError(t, err)
+
+	o := models.Options{Targeting: []models.TargetingRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com"}}}
+	set := func(u *repositories.URL) error {
+		u.Options = o
+		return nil
+	}
+
+	err = s.UpdateOptions("asdf", "other", set)
+	assert.ErrorIs(t, err, storage.ErrorNoLinkFound)
+
+	err = s.UpdateOptions("asdf", "user", func(u *repositories.URL) error {
+		return errors.New("invalid options")
+	})
+	assert.Error(t, err)
+
+	got, err := s.GetOptions("asdf", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Options{}, got)
+
+	err = s.UpdateOptions("asdf", "user", set)
+	assert.NoError(t, err)
+
+	got, err = s.GetOptions("asdf", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, &o, got)
+
+	u, err := s.Find("asdf")
+	assert.NoError(t, err)
+	assert.Equal(t, o, u.Options)
+}
+
+func TestMemory_AddFlag_RemoveFlag(t *testing.T) {
+	s := NewMemory(map[string]string{})
+	err := s.Save(&models.URL{URL: "https://yandex.ru", ShortURL: "asdf", UserID: "user"})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, s.AddFlag("qwer", "moderator"), storage.ErrorNoLinkFound)
+
+	err = s.UpdateOptions("asdf", "user", func(u *repositories.URL) error {
+		u.Flags = []string{"homograph"}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.AddFlag("asdf", "moderator"))
+	assert.NoError(t, s.AddFlag("asdf", "moderator"))
+
+	got, err := s.GetOptions("asdf", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"homograph", "moderator"}, got.Flags)
+
+	assert.NoError(t, s.RemoveFlag("asdf", "moderator"))
+
+	got, err = s.GetOptions("asdf", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"homograph"}, got.Flags)
+}
+
+func TestMemory_AddClick(t *testing.T) {
+	s := NewMemory(map[string]string{})
+
+	for _, v := range []string{"a", "b", "a"} {
+		err := s.AddClick("asdf", v)
+		assert.NoError(t, err)
+	}
+
+	got, err := s.GetClicks("asdf")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint{"a": 2, "b": 1}, got)
+
+	got, err = s.GetClicks("qwer")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -5,13 +5,18 @@ package pg
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	_ "github.com/jackc/pgx/v4/stdlib"
@@ -28,6 +33,9 @@ type pg struct {
 
 	// deleteBuffer for URLs deletion
 	deleteBuffer chan repositories.URL
+
+	// dedup is a scope, where duplicates of original URLs are searched.
+	dedup models.DedupScope
 }
 
 var _ repositories.ShortenerRepository = &pg{}
@@ -39,28 +47,321 @@ func NewConnection(dsn string) (*pg, error) {
 		return nil, err
 	}
 
-	return &pg{db: conn, buffer: make([]repositories.URL, 0, 1000), deleteBuffer: make(chan repositories.URL, 1)}, nil
+	return &pg{db: conn, buffer: make([]repositories.URL, 0, 1000), deleteBuffer: make(chan repositories.URL, 1), dedup: models.DedupGlobal}, nil
+}
+
+// SetDedupScope sets scope, where duplicates of original URLs are searched,
+// and creates unique index, that the scope requires. Nothing is changed,
+// if index of the scope exists already. Otherwise index is built
+// concurrently without timeout, so writes aren't blocked, while it is
+// built. If duplicates of the scope are stored, index can't be created,
+// so scope is not changed and error, that lists them, is returned, scope
+// of existing index is used.
+func (p *pg) SetDedupScope(scope models.DedupScope) error {
+	var queries []string
+	var duplicates string
+	switch scope {
+	case models.DedupGlobal:
+		duplicates = `SELECT canonical_url, string_agg(short_url, ', ') FROM shortener.shortener GROUP BY canonical_url HAVING count(*) > 1 LIMIT 10`
+		queries = []string{
+			`DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_idx`,
+			`CREATE UNIQUE INDEX CONCURRENTLY canonical_url_idx ON shortener.shortener(canonical_url)`,
+			`DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_user_idx`,
+		}
+	case models.DedupUser:
+		duplicates = `SELECT canonical_url, string_agg(short_url, ', ') FROM shortener.shortener GROUP BY user_id, canonical_url HAVING count(*) > 1 LIMIT 10`
+		queries = []string{
+			`DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_user_idx`,
+			`CREATE UNIQUE INDEX CONCURRENTLY canonical_url_user_idx ON shortener.shortener(user_id, canonical_url)`,
+			`DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_idx`,
+		}
+	case models.DedupNone:
+		queries = []string{
+			`DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_idx`,
+			`DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_user_idx`,
+		}
+	default:
+		return fmt.Errorf("unknown dedup scope %q", scope)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	current, err := p.currentDedupScope(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("dedup scope %s: %w", scope, err)
+	}
+
+	if current == scope {
+		p.dedup = scope
+		return nil
+	}
+
+	ctx = context.Background()
+
+	if duplicates != "" {
+		found, err := p.findDuplicates(ctx, duplicates)
+		if err != nil {
+			return fmt.Errorf("dedup scope %s: %w", scope, err)
+		}
+
+		if len(found) > 0 {
+			p.dedup = current
+
+			return fmt.Errorf("dedup scope %s is not set, scope %s is kept: %w, delete or change them to set the scope: %s",
+				scope, current, storage.ErrorDuplicateURLs, strings.Join(found, "; "))
+		}
+	}
+
+	for _, q := range queries {
+		if _, err := p.db.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("dedup scope %s: %w", scope, err)
+		}
+	}
+
+	p.dedup = scope
+	return nil
+}
+
+// findDuplicates returns canonical URLs with their short URLs, that
+// are found by query.
+func (p *pg) findDuplicates(ctx context.Context, query string) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []string
+	for rows.Next() {
+		var canonical, shortURLs string
+		if err := rows.Scan(&canonical, &shortURLs); err != nil {
+			return nil, err
+		}
+		found = append(found, fmt.Sprintf("%s (%s)", canonical, shortURLs))
+	}
+
+	return found, rows.Err()
+}
+
+// currentDedupScope returns scope of valid unique index of canonical
+// URLs, that exists in database. Index, that failed to be built
+// concurrently, is invalid.
+func (p *pg) currentDedupScope(ctx context.Context) (models.DedupScope, error) {
+	query := `SELECT c.relname FROM pg_index i JOIN pg_class c ON c.oid=i.indexrelid JOIN pg_namespace n ON n.oid=c.relnamespace
+		WHERE n.nspname='shortener' AND c.relname IN ('canonical_url_idx', 'canonical_url_user_idx') AND i.indisvalid`
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	scope := models.DedupNone
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			return "", err
+		}
+
+		switch index {
+		case "canonical_url_idx":
+			scope = models.DedupGlobal
+		case "canonical_url_user_idx":
+			if scope != models.DedupGlobal {
+				scope = models.DedupUser
+			}
+		}
+	}
+
+	return scope, rows.Err()
 }
 
 // CreateShortenerTable creates required fields
-// in database.
+// in database, by applying migrations in order.
+// Names of applied migrations are stored in
+// shortener.migrations, so each migration is
+// applied once.
 func (p *pg) CreateShortenerTable() error {
-	sql, err := os.ReadFile("./migrations/001_migration.sql")
+	migrations, err := filepath.Glob("./migrations/*.sql")
 	if err != nil {
 		return err
 	}
+	sort.Strings(migrations)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	_, err = p.db.ExecContext(ctx, string(sql))
-	if err != nil {
+	query := `CREATE SCHEMA IF NOT EXISTS shortener;
+CREATE TABLE IF NOT EXISTS shortener.migrations(
+    name varchar(255) PRIMARY KEY,
+    applied_at timestamptz NOT NULL DEFAULT now()
+);`
+	if _, err = p.db.ExecContext(ctx, query); err != nil {
 		return err
 	}
 
+	for _, m := range migrations {
+		if err := p.applyMigration(ctx, m); err != nil {
+			return fmt.Errorf("migration %s: %w", m, err)
+		}
+	}
+
 	return nil
 }
 
+// applyMigration applies migration at path in transaction,
+// unless it is applied already.
+func (p *pg) applyMigration(ctx context.Context, path string) error {
+	name := filepath.Base(path)
+
+	var applied bool
+	query := `SELECT EXISTS(SELECT 1 FROM shortener.migrations WHERE name=$1)`
+	if err := p.db.QueryRowContext(ctx, query, name).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	sql, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, string(sql)); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO shortener.migrations(name) VALUES($1)`, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const (
+	// canonicalBackfill is a name, that backfill of canonical form is
+	// recorded by in shortener.migrations and its progress is recorded
+	// by in shortener.backfills.
+	canonicalBackfill = "005_canonical_url_backfill"
+
+	// backfillBatchSize is a number of rows, that are backfilled at once.
+	backfillBatchSize = 1000
+
+	// backfillBatchTimeout is a timeout of backfill of a batch.
+	backfillBatchTimeout = 30 * time.Second
+)
+
+// BackfillCanonicalURLs replaces canonical form of short URLs, that
+// were stored before canonicalization, and got original URL as
+// canonical form by migration 005, by canonical form of canonicalize.
+// Rows are backfilled in batches in order of identificator, that is
+// recorded after each batch, so interrupted backfill continues after
+// the last batch. It is done once, short URL, whose canonical form is
+// taken by another one, keeps original URL as canonical form.
+func (p *pg) BackfillCanonicalURLs(canonicalize func(u string) (string, error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	var applied bool
+	query := `SELECT EXISTS(SELECT 1 FROM shortener.migrations WHERE name=$1)`
+	if err := p.db.QueryRowContext(ctx, query, canonicalBackfill).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	var last string
+	query = `SELECT last_id FROM shortener.backfills WHERE name=$1`
+	if err := p.db.QueryRowContext(ctx, query, canonicalBackfill).Scan(&last); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for {
+		n, err := p.backfillCanonicalBatch(canonicalize, &last)
+		if err != nil {
+			return fmt.Errorf("backfill canonical form after %q: %w", last, err)
+		}
+
+		if n < backfillBatchSize {
+			break
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, `INSERT INTO shortener.migrations(name) VALUES($1)`, canonicalBackfill)
+	return err
+}
+
+// backfillCanonicalBatch backfills canonical form of a batch of rows,
+// whose identificator is greater than last, and sets last to
+// identificator of the last row of the batch. It returns number of
+// rows of the batch.
+func (p *pg) backfillCanonicalBatch(canonicalize func(u string) (string, error), last *string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backfillBatchTimeout)
+	defer cancel()
+
+	query := `SELECT correlation_id, short_url, original_url FROM shortener.shortener
+		WHERE canonical_url=original_url AND ($1='' OR correlation_id > $1::uuid) ORDER BY correlation_id LIMIT $2`
+	rows, err := p.db.QueryContext(ctx, query, *last, backfillBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	var URLs []repositories.URL
+	for rows.Next() {
+		var id string
+		var u repositories.URL
+		if err := rows.Scan(&id, &u.ShortURL, &u.URL); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+		URLs = append(URLs, u)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(URLs) == 0 {
+		return 0, nil
+	}
+
+	for _, u := range URLs {
+		canonical, err := canonicalize(u.URL)
+		if err != nil || canonical == u.URL {
+			continue
+		}
+
+		_, err = p.db.ExecContext(ctx, `UPDATE shortener.shortener SET canonical_url=$1 WHERE short_url=$2`, canonical, u.ShortURL)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", u.ShortURL, err)
+		}
+	}
+
+	query = `INSERT INTO shortener.backfills(name, last_id) VALUES($1, $2)
+		ON CONFLICT (name) DO UPDATE SET last_id=EXCLUDED.last_id`
+	if _, err := p.db.ExecContext(ctx, query, canonicalBackfill, ids[len(ids)-1]); err != nil {
+		return 0, err
+	}
+	*last = ids[len(ids)-1]
+
+	return len(URLs), nil
+}
+
 // Ping implements repositories.ShortenerRepository Ping method.
 func (p *pg) Ping() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -76,13 +377,21 @@ func (p *pg) Find(sURL string) (*repositories.URL, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	sql := `SELECT original_url, is_deleted FROM shortener.shortener WHERE short_url=$1`
+	query := `SELECT original_url, is_deleted, disabled_reason, options, created_at FROM shortener.shortener WHERE short_url=$1`
 
 	URL := &repositories.URL{}
+	var options []byte
+
+	row := p.db.QueryRowContext(ctx, query, sURL)
 
-	row := p.db.QueryRowContext(ctx, sql, sURL)
+	if err := row.Scan(&URL.URL, &URL.IsDeleted, &URL.DisabledReason, &options, &URL.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrorNoLinkFound
+		}
+		return nil, err
+	}
 
-	if err := row.Scan(&URL.URL, &URL.IsDeleted); err != nil {
+	if err := json.Unmarshal(options, &URL.Options); err != nil {
 		return nil, err
 	}
 
@@ -94,18 +403,31 @@ func (p *pg) Save(url *models.URL) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	sql := `INSERT INTO shortener.shortener(short_url, original_url, user_id) VALUES($1, $2, $3)`
+	options, err := json.Marshal(url.Options)
+	if err != nil {
+		return err
+	}
 
-	_, err := p.db.ExecContext(ctx, sql, url.ShortURL, url.URL, url.UserID)
+	canonical := canonicalURL(url.URL, url.CanonicalURL)
+	query := `INSERT INTO shortener.shortener(short_url, original_url, canonical_url, user_id, options) VALUES($1, $2, $3, $4, $5)`
+
+	_, err = p.db.ExecContext(ctx, query, url.ShortURL, url.URL, canonical, url.UserID, string(options))
 	if err == nil {
 		return nil
 	}
 
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-		sql := `SELECT short_url FROM shortener.shortener WHERE original_url=$1`
-		row := p.db.QueryRowContext(ctx, sql, url.URL)
-		if err = row.Scan(&url.ShortURL); err != nil {
+		var row *sql.Row
+		if p.dedup == models.DedupUser {
+			query = `SELECT short_url FROM shortener.shortener WHERE canonical_url=$1 and user_id=$2`
+			row = p.db.QueryRowContext(ctx, query, canonical, url.UserID)
+		} else {
+			query = `SELECT short_url FROM shortener.shortener WHERE canonical_url=$1`
+			row = p.db.QueryRowContext(ctx, query, canonical)
+		}
+
+		if err := row.Scan(&url.ShortURL); err != nil {
 			return err
 		}
 	}
@@ -118,7 +440,7 @@ func (p *pg) GetUserURLs(user string, baseURL string) (URLs []repositories.URL,
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	sql := `SELECT short_url, original_url FROM shortener.shortener WHERE is_deleted=false and user_id=$1`
+	sql := `SELECT short_url, original_url, options FROM shortener.shortener WHERE is_deleted=false and user_id=$1`
 
 	rows, err := p.db.QueryContext(ctx, sql, user)
 	if err != nil {
@@ -127,7 +449,12 @@ func (p *pg) GetUserURLs(user string, baseURL string) (URLs []repositories.URL,
 
 	for rows.Next() {
 		var URL repositories.URL
-		if err := rows.Scan(&URL.ShortURL, &URL.URL); err != nil {
+		var options []byte
+		if err := rows.Scan(&URL.ShortURL, &URL.URL, &options); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(options, &URL.Options); err != nil {
 			return nil, err
 		}
 
@@ -141,6 +468,382 @@ func (p *pg) GetUserURLs(user string, baseURL string) (URLs []repositories.URL,
 	return
 }
 
+// GetUserURLsPage implements repositories.ShortenerRepository GetUserURLsPage method.
+func (p *pg) GetUserURLsPage(user string, after string, limit int) ([]repositories.URL, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sql := `SELECT short_url, original_url, options, created_at FROM shortener.shortener WHERE is_deleted=false and user_id=$1 and short_url>$2 ORDER BY short_url LIMIT $3`
+
+	rows, err := p.db.QueryContext(ctx, sql, user, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	URLs := make([]repositories.URL, 0, limit)
+	for rows.Next() {
+		URL := repositories.URL{UserID: user}
+		var options []byte
+		if err := rows.Scan(&URL.ShortURL, &URL.URL, &options, &URL.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(options, &URL.Options); err != nil {
+			return nil, err
+		}
+
+		URLs = append(URLs, URL)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return URLs, nil
+}
+
+// GetOptions implements repositories.ShortenerRepository GetOptions method.
+func (p *pg) GetOptions(shortURL string, user string) (*models.Options, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	query := `SELECT options FROM shortener.shortener WHERE short_url=$1 and user_id=$2 and is_deleted=false`
+
+	var options []byte
+	row := p.db.QueryRowContext(ctx, query, shortURL, user)
+	if err := row.Scan(&options); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrorNoLinkFound
+		}
+		return nil, err
+	}
+
+	o := &models.Options{}
+	if err := json.Unmarshal(options, o); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// UpdateOptions implements repositories.ShortenerRepository UpdateOptions method.
+// Row of short URL is locked by SELECT FOR UPDATE, until options are
+// replaced.
+func (p *pg) UpdateOptions(shortURL string, user string, update func(u *repositories.URL) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT original_url, options FROM shortener.shortener WHERE short_url=$1 and user_id=$2 and is_deleted=false FOR UPDATE`
+
+	u := &repositories.URL{ShortURL: shortURL, UserID: user}
+	var options []byte
+	if err := tx.QueryRowContext(ctx, query, shortURL, user).Scan(&u.URL, &options); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrorNoLinkFound
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(options, &u.Options); err != nil {
+		return err
+	}
+
+	if err := update(u); err != nil {
+		return err
+	}
+
+	options, err = json.Marshal(u.Options)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE shortener.shortener SET options=$1 WHERE short_url=$2`, string(options), shortURL); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddClick implements repositories.ShortenerRepository AddClick method.
+func (p *pg) AddClick(shortURL string, variant string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	sql := `INSERT INTO shortener.clicks(short_url, variant, clicks) VALUES($1, $2, 1)
+		ON CONFLICT (short_url, variant) DO UPDATE SET clicks = shortener.clicks.clicks + 1`
+
+	_, err := p.db.ExecContext(ctx, sql, shortURL, variant)
+	return err
+}
+
+// GetClicks implements repositories.ShortenerRepository GetClicks method.
+func (p *pg) GetClicks(shortURL string) (map[string]uint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	sql := `SELECT variant, clicks FROM shortener.clicks WHERE short_url=$1`
+
+	rows, err := p.db.QueryContext(ctx, sql, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clicks := make(map[string]uint)
+	for rows.Next() {
+		var (
+			variant string
+			n       uint
+		)
+		if err := rows.Scan(&variant, &n); err != nil {
+			return nil, err
+		}
+		clicks[variant] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clicks, nil
+}
+
+// GetEnabledURLs implements repositories.ShortenerRepository GetEnabledURLs method.
+func (p *pg) GetEnabledURLs() ([]repositories.URL, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sql := `SELECT short_url, original_url, user_id, options FROM shortener.shortener WHERE is_deleted=false and disabled_reason=''`
+
+	rows, err := p.db.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var URLs []repositories.URL
+	for rows.Next() {
+		var URL repositories.URL
+		var options []byte
+		if err := rows.Scan(&URL.ShortURL, &URL.URL, &URL.UserID, &options); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(options, &URL.Options); err != nil {
+			return nil, err
+		}
+
+		URLs = append(URLs, URL)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return URLs, nil
+}
+
+// DisableURLs implements repositories.ShortenerRepository DisableURLs method.
+func (p *pg) DisableURLs(reason string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("UPDATE shortener.shortener SET disabled_reason=$1 WHERE short_url=$2")
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	for _, u := range shortURLs {
+		if _, err := stmt.Exec(reason, u); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddFlag implements repositories.ShortenerRepository AddFlag method.
+// Flag is appended by a single statement, so concurrent changes of
+// options and other flags are kept.
+func (p *pg) AddFlag(shortURL string, flag string) error {
+	query := `UPDATE shortener.shortener SET options=CASE
+			WHEN coalesce(options->'flags', '[]'::jsonb) ? $1 THEN options
+			ELSE jsonb_set(options, '{flags}', coalesce(options->'flags', '[]'::jsonb) || to_jsonb($1::text))
+		END WHERE short_url=$2`
+
+	return p.updateFlags(query, shortURL, flag)
+}
+
+// RemoveFlag implements repositories.ShortenerRepository RemoveFlag method.
+// Flag is removed by a single statement, so concurrent changes of
+// options and other flags are kept.
+func (p *pg) RemoveFlag(shortURL string, flag string) error {
+	query := `UPDATE shortener.shortener SET options=CASE
+			WHEN coalesce(options->'flags', '[]'::jsonb) - $1 = '[]'::jsonb THEN options - 'flags'
+			ELSE jsonb_set(options, '{flags}', (options->'flags') - $1)
+		END WHERE short_url=$2`
+
+	return p.updateFlags(query, shortURL, flag)
+}
+
+// updateFlags changes flags of short URL by query.
+func (p *pg) updateFlags(query string, shortURL string, flag string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	result, err := p.db.ExecContext(ctx, query, flag, shortURL)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return storage.ErrorNoLinkFound
+	}
+
+	return nil
+}
+
+// EnableURLs implements repositories.ShortenerRepository EnableURLs method.
+func (p *pg) EnableURLs(shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("UPDATE shortener.shortener SET disabled_reason='' WHERE short_url=$1")
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	for _, u := range shortURLs {
+		if _, err := stmt.Exec(u); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddReport implements repositories.ShortenerRepository AddReport method.
+func (p *pg) AddReport(r *models.Report) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO shortener.reports (short_url, reason, reporter, ip, status) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+
+	row := p.db.QueryRowContext(ctx, query, r.ShortURL, r.Reason, r.Reporter, r.IP, r.Status)
+	return row.Scan(&r.ID, &r.CreatedAt)
+}
+
+// GetReports implements repositories.ShortenerRepository GetReports method.
+func (p *pg) GetReports(status models.ReportStatus) ([]models.Report, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT id, short_url, reason, reporter, ip, status, created_at FROM shortener.reports WHERE $1='' OR status=$1 ORDER BY id`
+
+	rows, err := p.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []models.Report{}
+	for rows.Next() {
+		var r models.Report
+		if err := rows.Scan(&r.ID, &r.ShortURL, &r.Reason, &r.Reporter, &r.IP, &r.Status, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// ResolveReports implements repositories.ShortenerRepository ResolveReports method.
+func (p *pg) ResolveReports(shortURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	query := `UPDATE shortener.reports SET status=$1 WHERE short_url=$2 AND status=$3`
+
+	_, err := p.db.ExecContext(ctx, query, models.ReportResolved, shortURL, models.ReportOpen)
+	return err
+}
+
+// AddAuditEntry implements repositories.ShortenerRepository AddAuditEntry method.
+func (p *pg) AddAuditEntry(e *models.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	query := `INSERT INTO shortener.audit_log (action, short_url, actor, reason) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	row := p.db.QueryRowContext(ctx, query, e.Action, e.ShortURL, e.Actor, e.Reason)
+	return row.Scan(&e.ID, &e.CreatedAt)
+}
+
+// GetAuditLog implements repositories.ShortenerRepository GetAuditLog method.
+func (p *pg) GetAuditLog(shortURL string) ([]models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT id, action, short_url, actor, reason, created_at FROM shortener.audit_log WHERE $1='' OR short_url=$1 ORDER BY id`
+
+	rows, err := p.db.QueryContext(ctx, query, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.ShortURL, &e.Actor, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 // AddURLBuffer implements repositories.ShortenerRepository AddURLBuffer method.
 func (p *pg) AddURLBuffer(u repositories.URL) error {
 	p.buffer = append(p.buffer, u)
@@ -165,13 +868,22 @@ func (p *pg) Flush() error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("INSERT INTO shortener.shortener(correlation_id, short_url, original_url, user_id) VALUES($1, $2, $3, $4)")
+	stmt, err := tx.Prepare("INSERT INTO shortener.shortener(correlation_id, short_url, original_url, canonical_url, user_id, options) VALUES($1, $2, $3, $4, $5, $6)")
 	if err != nil {
 		return err
 	}
 
 	for _, v := range p.buffer {
-		if _, err := stmt.Exec(v.CorrelationID, v.ShortURL, v.URL, v.UserID); err != nil {
+		options, err := json.Marshal(v.Options)
+		if err != nil {
+			p.buffer = p.buffer[:0]
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+
+		if _, err := stmt.Exec(v.CorrelationID, v.ShortURL, v.URL, canonicalURL(v.URL, v.CanonicalURL), v.UserID, string(options)); err != nil {
 			p.buffer = p.buffer[:0]
 			if err = tx.Rollback(); err != nil {
 				return err
@@ -227,6 +939,16 @@ func (p *pg) FlushToDelete() error {
 	}
 }
 
+// canonicalURL returns canonical form of original URL, original URL
+// is its own canonical form, if canonical form is not set.
+func canonicalURL(original string, canonical string) string {
+	if canonical == "" {
+		return original
+	}
+
+	return canonical
+}
+
 // Close closes database connection.
 func (p *pg) Close() error {
 	return p.db.Close()
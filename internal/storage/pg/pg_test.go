@@ -3,15 +3,23 @@
 package pg
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"log"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/Fe4p3b/url-shortener/internal/models"
 	"github.com/Fe4p3b/url-shortener/internal/repositories"
+	"github.com/Fe4p3b/url-shortener/internal/storage"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/stretchr/testify/assert"
 )
@@ -70,9 +78,10 @@ func Test_pg_Find(t *testing.T) {
 		deleteBuffer chan repositories.URL
 	}
 	type args struct {
-		sURL  string
-		query string
-		URL   repositories.URL
+		sURL    string
+		query   string
+		URL     repositories.URL
+		options string
 	}
 	tests := []struct {
 		name    string
@@ -90,15 +99,19 @@ func Test_pg_Find(t *testing.T) {
 			},
 			args: args{
 				sURL:  "asdf",
-				query: "SELECT original_url, is_deleted FROM shortener.shortener WHERE short_url=$1",
+				query: "SELECT original_url, is_deleted, disabled_reason, options, created_at FROM shortener.shortener WHERE short_url=$1",
 				URL: repositories.URL{
 					URL:       "http://google.com",
 					IsDeleted: false,
+					CreatedAt: time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC),
 				},
+				options: `{"query_policy":"keep"}`,
 			},
 			want: &repositories.URL{
 				URL:       "http://google.com",
 				IsDeleted: false,
+				CreatedAt: time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC),
+				Options:   models.Options{QueryPolicy: models.QueryKeep},
 			},
 		},
 	}
@@ -109,8 +122,8 @@ func Test_pg_Find(t *testing.T) {
 				buffer:       tt.fields.buffer,
 				deleteBuffer: tt.fields.deleteBuffer,
 			}
-			rows := sqlmock.NewRows([]string{"original_url", "is_deleted"}).
-				AddRow(tt.args.URL.URL, tt.args.URL.IsDeleted)
+			rows := sqlmock.NewRows([]string{"original_url", "is_deleted", "disabled_reason", "options", "created_at"}).
+				AddRow(tt.args.URL.URL, tt.args.URL.IsDeleted, tt.args.URL.DisabledReason, tt.args.options, tt.args.URL.CreatedAt)
 			mock.ExpectQuery(regexp.QuoteMeta(tt.args.query)).WithArgs(tt.args.sURL).WillReturnRows(rows)
 
 			got, err := p.Find(tt.args.sURL)
@@ -148,11 +161,12 @@ func Test_pg_Store(t *testing.T) {
 				deleteBuffer: make(chan repositories.URL),
 			},
 			args: args{
-				query: "INSERT INTO shortener.shortener(short_url, original_url, user_id) VALUES($1, $2, $3)",
+				query: "INSERT INTO shortener.shortener(short_url, original_url, canonical_url, user_id, options) VALUES($1, $2, $3, $4, $5)",
 				URL: models.URL{
-					URL:      "http://google.com",
-					UserID:   "1234",
-					ShortURL: "asdf",
+					URL:          "HTTP://Google.com",
+					CanonicalURL: "http://google.com",
+					UserID:       "1234",
+					ShortURL:     "asdf",
 				},
 			},
 		},
@@ -166,7 +180,7 @@ func Test_pg_Store(t *testing.T) {
 			}
 
 			prep := mock.ExpectExec(regexp.QuoteMeta(tt.args.query))
-			prep.WithArgs(tt.args.URL.ShortURL, tt.args.URL.URL, tt.args.URL.UserID).WillReturnResult(sqlmock.NewResult(0, 1))
+			prep.WithArgs(tt.args.URL.ShortURL, tt.args.URL.URL, tt.args.URL.CanonicalURL, tt.args.URL.UserID, "{}").WillReturnResult(sqlmock.NewResult(0, 1))
 
 			err := p.Save(&tt.args.URL)
 			assert.NoError(t, err)
@@ -403,7 +417,7 @@ func Test_pg_Flush(t *testing.T) {
 					},
 				},
 			},
-			query:   "INSERT INTO shortener.shortener(correlation_id, short_url, original_url, user_id) VALUES($1, $2, $3, $4)",
+			query:   "INSERT INTO shortener.shortener(correlation_id, short_url, original_url, canonical_url, user_id, options) VALUES($1, $2, $3, $4, $5, $6)",
 			wantErr: false,
 		},
 	}
@@ -417,7 +431,7 @@ func Test_pg_Flush(t *testing.T) {
 			prep := mock.ExpectPrepare(regexp.QuoteMeta(tt.query))
 			for _, arg := range p.buffer {
 
-				prep.ExpectExec().WithArgs(arg.CorrelationID, arg.ShortURL, arg.URL, arg.UserID).WillReturnResult(sqlmock.NewResult(0, 1))
+				prep.ExpectExec().WithArgs(arg.CorrelationID, arg.ShortURL, arg.URL, arg.URL, arg.UserID, "{}").WillReturnResult(sqlmock.NewResult(0, 1))
 			}
 			mock.ExpectCommit()
 
@@ -456,7 +470,7 @@ func Test_pg_GetUserURLs(t *testing.T) {
 			args: args{
 				user:    "asdf",
 				baseURL: "localhost:8080",
-				query:   "SELECT short_url, original_url FROM shortener.shortener WHERE is_deleted=false and user_id=$1",
+				query:   "SELECT short_url, original_url, options FROM shortener.shortener WHERE is_deleted=false and user_id=$1",
 				URL: repositories.URL{
 					ShortURL: "qwer",
 					URL:      "http://google.com",
@@ -476,8 +490,8 @@ func Test_pg_GetUserURLs(t *testing.T) {
 				db: tt.fields.db,
 			}
 
-			rows := sqlmock.NewRows([]string{"short_url", "original_url"}).
-				AddRow(tt.args.URL.ShortURL, tt.args.URL.URL)
+			rows := sqlmock.NewRows([]string{"short_url", "original_url", "options"}).
+				AddRow(tt.args.URL.ShortURL, tt.args.URL.URL, "{}")
 			mock.ExpectQuery(regexp.QuoteMeta(tt.args.query)).WithArgs(tt.args.user).WillReturnRows(rows)
 
 			gotURLs, err := p.GetUserURLs(tt.args.user, tt.args.baseURL)
@@ -545,3 +559,407 @@ func Test_pg_FlushToDelete(t *testing.T) {
 		})
 	}
 }
+
+func Test_pg_UpdateOptions(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	selectQuery := "SELECT original_url, options FROM shortener.shortener WHERE short_url=$1 and user_id=$2 and is_deleted=false FOR UPDATE"
+	updateQuery := "UPDATE shortener.shortener SET options=$1 WHERE short_url=$2"
+
+	type args struct {
+		shortURL string
+		user     string
+		current  string
+		found    bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    models.Options
+		wantErr error
+	}{
+		{
+			name: "Test case #1",
+			args: args{
+				shortURL: "asdf",
+				user:     "1",
+				current:  `{"title":"Yandex","flags":["moderator"]}`,
+				found:    true,
+			},
+			want: models.Options{
+				Title:     "Yandex",
+				Targeting: []models.TargetingRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com"}},
+				Flags:     []string{"moderator"},
+			},
+		},
+		{
+			name: "Test case #2",
+			args: args{
+				shortURL: "asdf",
+				user:     "2",
+			},
+			wantErr: storage.ErrorNoLinkFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pg{
+				db: db,
+			}
+
+			mock.ExpectBegin()
+			rows := sqlmock.NewRows([]string{"original_url", "options"})
+			if tt.args.found {
+				rows.AddRow("https://yandex.ru", tt.args.current)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+				WithArgs(tt.args.shortURL, tt.args.user).
+				WillReturnRows(rows)
+
+			if tt.wantErr == nil {
+				options, err := json.Marshal(tt.want)
+				assert.NoError(t, err)
+
+				mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+					WithArgs(string(options), tt.args.shortURL).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := p.UpdateOptions(tt.args.shortURL, tt.args.user, func(u *repositories.URL) error {
+				assert.Equal(t, "https://yandex.ru", u.URL)
+				u.Targeting = []models.TargetingRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com"}}
+				return nil
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_pg_AddFlag_RemoveFlag(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	p := &pg{
+		db: db,
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`ELSE jsonb_set(options, '{flags}', coalesce(options->'flags', '[]'::jsonb) || to_jsonb($1::text))`)).
+		WithArgs("moderator", "asdf").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, p.AddFlag("asdf", "moderator"))
+
+	mock.ExpectExec(regexp.QuoteMeta(`ELSE jsonb_set(options, '{flags}', (options->'flags') - $1)`)).
+		WithArgs("moderator", "asdf").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, p.RemoveFlag("asdf", "moderator"))
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE shortener.shortener SET options=CASE`)).
+		WithArgs("moderator", "qwer").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, p.AddFlag("qwer", "moderator"), storage.ErrorNoLinkFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_pg_applyMigration(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "001_migration.sql")
+	migration := "CREATE UNIQUE INDEX IF NOT EXISTS original_url_idx on shortener.shortener(original_url);"
+	assert.NoError(t, os.WriteFile(path, []byte(migration), 0644))
+
+	tests := []struct {
+		name    string
+		applied bool
+	}{
+		{name: "Test case #1", applied: true},
+		{name: "Test case #2", applied: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pg{db: db}
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM shortener.migrations WHERE name=$1)")).
+				WithArgs("001_migration.sql").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tt.applied))
+			if !tt.applied {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(migration)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO shortener.migrations(name) VALUES($1)")).
+					WithArgs("001_migration.sql").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			assert.NoError(t, p.applyMigration(context.Background(), path))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_pg_BackfillCanonicalURLs(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	canonicalize := func(u string) (string, error) {
+		return strings.ToLower(u), nil
+	}
+
+	tests := []struct {
+		name     string
+		applied  bool
+		progress string
+		rows     [][3]string
+		updates  map[string]error
+	}{
+		{name: "Test case #1", applied: true},
+		{
+			name: "Test case #2",
+			rows: [][3]string{
+				{"00000000-0000-0000-0000-000000000001", "asdf", "http://Yandex.ru"},
+				{"00000000-0000-0000-0000-000000000002", "qwer", "http://yandex.ru"},
+				{"00000000-0000-0000-0000-000000000003", "zxcv", "http://YANDEX.ru"},
+			},
+			updates: map[string]error{
+				"asdf": nil,
+				"zxcv": &pgconn.PgError{Code: pgerrcode.UniqueViolation},
+			},
+		},
+		{
+			name:     "Test case #3",
+			progress: "00000000-0000-0000-0000-000000000003",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pg{db: db}
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS(SELECT 1 FROM shortener.migrations WHERE name=$1)")).
+				WithArgs(canonicalBackfill).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tt.applied))
+			if !tt.applied {
+				progress := sqlmock.NewRows([]string{"last_id"})
+				if tt.progress != "" {
+					progress.AddRow(tt.progress)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT last_id FROM shortener.backfills WHERE name=$1")).
+					WithArgs(canonicalBackfill).
+					WillReturnRows(progress)
+
+				rows := sqlmock.NewRows([]string{"correlation_id", "short_url", "original_url"})
+				for _, r := range tt.rows {
+					rows.AddRow(r[0], r[1], r[2])
+				}
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT correlation_id, short_url, original_url FROM shortener.shortener")).
+					WithArgs(tt.progress, backfillBatchSize).
+					WillReturnRows(rows)
+				for _, r := range tt.rows {
+					err, ok := tt.updates[r[1]]
+					if !ok {
+						continue
+					}
+
+					e := mock.ExpectExec(regexp.QuoteMeta("UPDATE shortener.shortener SET canonical_url=$1 WHERE short_url=$2")).
+						WithArgs(strings.ToLower(r[2]), r[1])
+					if err != nil {
+						e.WillReturnError(err)
+					} else {
+						e.WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+				}
+				if len(tt.rows) > 0 {
+					mock.ExpectExec(regexp.QuoteMeta("INSERT INTO shortener.backfills(name, last_id) VALUES($1, $2)")).
+						WithArgs(canonicalBackfill, tt.rows[len(tt.rows)-1][0]).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO shortener.migrations(name) VALUES($1)")).
+					WithArgs(canonicalBackfill).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			assert.NoError(t, p.BackfillCanonicalURLs(canonicalize))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_pg_SetDedupScope(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	tests := []struct {
+		name       string
+		scope      models.DedupScope
+		indexes    []string
+		duplicates string
+		found      [][2]string
+		queries    []string
+		want       models.DedupScope
+		wantErr    error
+		contains   string
+	}{
+		{
+			name:       "Test case #1",
+			scope:      models.DedupGlobal,
+			duplicates: "SELECT canonical_url, string_agg(short_url, ', ') FROM shortener.shortener GROUP BY canonical_url HAVING count(*) > 1 LIMIT 10",
+			queries: []string{
+				"DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_idx",
+				"CREATE UNIQUE INDEX CONCURRENTLY canonical_url_idx ON shortener.shortener(canonical_url)",
+				"DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_user_idx",
+			},
+			want: models.DedupGlobal,
+		},
+		{
+			name:       "Test case #2",
+			scope:      models.DedupUser,
+			indexes:    []string{"canonical_url_idx"},
+			duplicates: "SELECT canonical_url, string_agg(short_url, ', ') FROM shortener.shortener GROUP BY user_id, canonical_url HAVING count(*) > 1 LIMIT 10",
+			queries: []string{
+				"DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_user_idx",
+				"CREATE UNIQUE INDEX CONCURRENTLY canonical_url_user_idx ON shortener.shortener(user_id, canonical_url)",
+				"DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_idx",
+			},
+			want: models.DedupUser,
+		},
+		{
+			name:    "Test case #3",
+			scope:   models.DedupNone,
+			indexes: []string{"canonical_url_idx"},
+			queries: []string{
+				"DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_idx",
+				"DROP INDEX CONCURRENTLY IF EXISTS shortener.canonical_url_user_idx",
+			},
+			want: models.DedupNone,
+		},
+		{
+			name:       "Test case #4",
+			scope:      models.DedupGlobal,
+			indexes:    []string{"canonical_url_user_idx"},
+			duplicates: "SELECT canonical_url, string_agg(short_url, ', ') FROM shortener.shortener GROUP BY canonical_url HAVING count(*) > 1 LIMIT 10",
+			found:      [][2]string{{"http://yandex.ru/", "asdf, qwer"}},
+			want:       models.DedupUser,
+			wantErr:    storage.ErrorDuplicateURLs,
+			contains:   "http://yandex.ru/ (asdf, qwer)",
+		},
+		{
+			name:    "Test case #5",
+			scope:   models.DedupGlobal,
+			indexes: []string{"canonical_url_idx"},
+			want:    models.DedupGlobal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pg{db: db}
+
+			indexes := sqlmock.NewRows([]string{"relname"})
+			for _, index := range tt.indexes {
+				indexes.AddRow(index)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT c.relname FROM pg_index i")).WillReturnRows(indexes)
+
+			if tt.duplicates != "" {
+				rows := sqlmock.NewRows([]string{"canonical_url", "short_urls"})
+				for _, r := range tt.found {
+					rows.AddRow(r[0], r[1])
+				}
+				mock.ExpectQuery(regexp.QuoteMeta(tt.duplicates)).WillReturnRows(rows)
+			}
+			for _, q := range tt.queries {
+				mock.ExpectExec(regexp.QuoteMeta(q)).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			err := p.SetDedupScope(tt.scope)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Contains(t, err.Error(), tt.contains)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, p.dedup)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_pg_Save_DedupUser(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	p := &pg{db: db, dedup: models.DedupUser}
+	u := &models.URL{URL: "http://google.com", CanonicalURL: "http://google.com", UserID: "1234", ShortURL: "qwer"}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO shortener.shortener(short_url, original_url, canonical_url, user_id, options) VALUES($1, $2, $3, $4, $5)")).
+		WithArgs(u.ShortURL, u.URL, u.CanonicalURL, u.UserID, "{}").
+		WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT short_url FROM shortener.shortener WHERE canonical_url=$1 and user_id=$2")).
+		WithArgs(u.CanonicalURL, u.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("asdf"))
+
+	err := p.Save(u)
+
+	var pgErr *pgconn.PgError
+	assert.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "asdf", u.ShortURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_pg_AddReport(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	p := &pg{
+		db: db,
+	}
+
+	createdAt := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO shortener.reports (short_url, reason, reporter, ip, status) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at")).
+		WithArgs("asdf", "phishing", "user", "127.0.0.1", "open").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+
+	r := &models.Report{ShortURL: "asdf", Reason: "phishing", Reporter: "user", IP: "127.0.0.1", Status: models.ReportOpen}
+	assert.NoError(t, p.AddReport(r))
+	assert.Equal(t, int64(1), r.ID)
+	assert.Equal(t, createdAt, r.CreatedAt)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE shortener.reports SET status=$1 WHERE short_url=$2 AND status=$3")).
+		WithArgs("resolved", "asdf", "open").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, p.ResolveReports("asdf"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_pg_GetUserURLsPage(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	p := &pg{
+		db: db,
+	}
+
+	createdAt := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT short_url, original_url, options, created_at FROM shortener.shortener WHERE is_deleted=false and user_id=$1 and short_url>$2 ORDER BY short_url LIMIT $3")).
+		WithArgs("user", "asdf", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "original_url", "options", "created_at"}).
+			AddRow("bsdf", "https://yandex.ru", []byte(`{"title":"Yandex"}`), createdAt).
+			AddRow("csdf", "https://practicum.yandex.ru", []byte(`{}`), createdAt))
+
+	URLs, err := p.GetUserURLsPage("user", "asdf", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []repositories.URL{
+		{ShortURL: "bsdf", URL: "https://yandex.ru", UserID: "user", CreatedAt: createdAt, Options: models.Options{Title: "Yandex"}},
+		{ShortURL: "csdf", URL: "https://practicum.yandex.ru", UserID: "user", CreatedAt: createdAt},
+	}, URLs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -7,3 +7,5 @@ var ErrorNoLinkFound = errors.New("link not found")
 var ErrorDuplicateShortlink = errors.New("duplicate short link")
 
 var ErrorMethodIsNotImplemented = errors.New("method is not implemented")
+
+var ErrorDuplicateURLs = errors.New("duplicates of original URLs are stored")
@@ -0,0 +1 @@
+ALTER TABLE shortener.shortener ADD COLUMN IF NOT EXISTS options jsonb NOT NULL DEFAULT '{}';
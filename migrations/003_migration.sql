@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS shortener.clicks(
+    short_url varchar(55) NOT NULL,
+    variant varchar(32) NOT NULL,
+    clicks bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (short_url, variant)
+);
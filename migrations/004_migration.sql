@@ -0,0 +1 @@
+ALTER TABLE shortener.shortener ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
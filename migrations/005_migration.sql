@@ -0,0 +1,9 @@
+ALTER TABLE shortener.shortener ADD COLUMN IF NOT EXISTS canonical_url varchar(255);
+
+UPDATE shortener.shortener SET canonical_url = original_url WHERE canonical_url IS NULL;
+
+ALTER TABLE shortener.shortener ALTER COLUMN canonical_url SET NOT NULL;
+
+DROP INDEX IF EXISTS shortener.original_url_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS canonical_url_idx ON shortener.shortener(canonical_url);
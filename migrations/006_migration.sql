@@ -0,0 +1 @@
+ALTER TABLE shortener.shortener ADD COLUMN IF NOT EXISTS disabled_reason text NOT NULL DEFAULT '';
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS shortener.reports(
+    id bigserial PRIMARY KEY,
+    short_url varchar(55) NOT NULL,
+    reason text NOT NULL,
+    reporter varchar(255) NOT NULL DEFAULT '',
+    ip varchar(64) NOT NULL DEFAULT '',
+    status varchar(16) NOT NULL DEFAULT 'open',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS reports_status_idx ON shortener.reports (status, id);
+
+CREATE TABLE IF NOT EXISTS shortener.audit_log(
+    id bigserial PRIMARY KEY,
+    action varchar(16) NOT NULL,
+    short_url varchar(55) NOT NULL,
+    actor varchar(255) NOT NULL DEFAULT '',
+    reason text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_short_url_idx ON shortener.audit_log (short_url, id);
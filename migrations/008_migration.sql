@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS shortener_user_id_short_url_idx ON shortener.shortener (user_id, short_url);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS shortener.backfills(
+    name varchar(255) PRIMARY KEY,
+    last_id uuid NOT NULL
+);
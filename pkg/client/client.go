@@ -0,0 +1,245 @@
+// Package client provides client of shortener for other services.
+// Client calls API of shortener over HTTP or gRPC on behalf of user,
+// that is identified by token, and Fake implements it in memory for
+// tests.
+//
+// Short URLs are accepted both with and without base URL, like
+// "http://localhost:8080/3Hlkv5jvg" or "3Hlkv5jvg". Redirects are
+// served to visitors by browser, so they aren't a part of client.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math/rand"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrorNotFound is returned, when short URL doesn't exist or
+	// isn't owned by user.
+	ErrorNotFound = errors.New("short URL not found")
+
+	// ErrorGone is returned, when short URL was deleted or disabled.
+	ErrorGone = errors.New("short URL is gone")
+
+	// ErrorConflict is returned, when original URL is already
+	// shortened.
+	ErrorConflict = errors.New("URL already exists")
+)
+
+const (
+	// TokenKey is a key of metadata of gRPC calls and a name of cookie
+	// of HTTP requests, that keeps token of user.
+	TokenKey = "token"
+
+	// NextPageTokenHeader is a header of HTTP response, that keeps
+	// token of the next page of URLs of user.
+	NextPageTokenHeader = "X-Next-Page-Token"
+
+	// DefaultRetries is a number of retries of idempotent calls, if
+	// it isn't set.
+	DefaultRetries = 3
+
+	// DefaultBackoff is a delay before the first retry, if it isn't set.
+	DefaultBackoff = 100 * time.Millisecond
+
+	// MaxBackoff is a maximum delay between retries.
+	MaxBackoff = 5 * time.Second
+
+	// DefaultPageSize is a number of URLs of page, if page size isn't
+	// set.
+	DefaultPageSize = 50
+
+	// MaxPageSize is a maximum number of URLs of page.
+	MaxPageSize = 1000
+)
+
+// Client calls API of shortener on behalf of user. Calls, that only
+// read or replace state, are retried on temporary failures.
+type Client interface {
+	// PostURL creates short URL of original URL with options. If
+	// original URL is already shortened, existing short URL is
+	// returned with ErrorConflict.
+	PostURL(ctx context.Context, originalURL string, o Options) (string, error)
+
+	// ShortenBatch creates short URLs of batch, that are matched with
+	// original URLs by correlation ID.
+	ShortenBatch(ctx context.Context, batch []URL) ([]URL, error)
+
+	// ExpandURL describes where short URL leads, deleted or disabled
+	// short URL is described by its state.
+	ExpandURL(ctx context.Context, shortURL string) (*Expansion, error)
+
+	// GetUserURLs returns all URLs of user.
+	GetUserURLs(ctx context.Context) ([]URL, error)
+
+	// GetUserURLsPage returns page of URLs of user and token of the
+	// next page, that is empty for the last page.
+	GetUserURLsPage(ctx context.Context, pageToken string, pageSize int) ([]URL, string, error)
+
+	// DeleteUserURLs deletes short URLs of user asynchronously.
+	DeleteUserURLs(ctx context.Context, URLs []string) error
+
+	// GetTargeting returns targeting rules of user's short URL.
+	GetTargeting(ctx context.Context, shortURL string) ([]TargetingRule, error)
+
+	// SetTargeting replaces all targeting rules of user's short URL by
+	// rules, nil or empty rules clear them.
+	SetTargeting(ctx context.Context, shortURL string, rules []TargetingRule) error
+
+	// GetGeo returns geo rules of user's short URL.
+	GetGeo(ctx context.Context, shortURL string) ([]GeoRule, error)
+
+	// SetGeo replaces all geo rules of user's short URL by rules, nil
+	// or empty rules clear them.
+	SetGeo(ctx context.Context, shortURL string, rules []GeoRule) error
+
+	// GetVariants returns variants of user's short URL with number of
+	// visits, that each variant was served.
+	GetVariants(ctx context.Context, shortURL string) ([]VariantStats, error)
+
+	// SetVariants replaces all variants of user's short URL by
+	// variants, nil or empty variants clear them.
+	SetVariants(ctx context.Context, shortURL string, variants []Variant) error
+
+	// Ping checks whether shortener and its storage are available.
+	Ping(ctx context.Context) error
+
+	// GetStats returns statistics of service, it is allowed only from
+	// trusted network.
+	GetStats(ctx context.Context) (*Stats, error)
+
+	// Token returns token of user, that was set by config or was
+	// issued by server for a new user.
+	Token() string
+
+	// Close releases connections of client, it should not be used
+	// after Close.
+	Close() error
+}
+
+// Config is a configuration of client.
+type Config struct {
+	// Address is an address of server, like "localhost:3200" for
+	// gRPC or "http://localhost:8080" for HTTP.
+	Address string
+
+	// TLS is a configuration of TLS, connection is insecure, if it
+	// is nil.
+	TLS *tls.Config
+
+	// Token is a token of user. If it is empty, server issues token
+	// for a new user on the first call.
+	Token string
+
+	// Timeout limits time of HTTP request, it is unlimited, if zero.
+	Timeout time.Duration
+
+	// Retries is a number of retries of idempotent calls, it is
+	// DefaultRetries, if zero, negative number disables retries.
+	Retries int
+
+	// Backoff is a delay before the first retry, it is doubled for
+	// each next retry up to MaxBackoff, it is DefaultBackoff, if zero.
+	Backoff time.Duration
+}
+
+// retry calls fn, until it succeeds, fails with error, that isn't
+// temporary, or retries are exhausted. Delays are randomized by half
+// to spread retries of clients.
+func (c Config) retry(ctx context.Context, temporary func(error) bool, fn func() error) error {
+	retries, backoff := c.Retries, c.Backoff
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || i >= retries || !temporary(err) {
+			return err
+		}
+
+		d := backoff << i
+		if d > MaxBackoff || d <= 0 {
+			d = MaxBackoff
+		}
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// tokenStore keeps token of user, it is safe for concurrent use.
+type tokenStore struct {
+	mu    sync.RWMutex
+	value string
+}
+
+func (t *tokenStore) get() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.value
+}
+
+func (t *tokenStore) set(value string) {
+	t.mu.Lock()
+	t.value = value
+	t.mu.Unlock()
+}
+
+// pageSize returns page size, that is limited by MaxPageSize, or
+// DefaultPageSize, if size isn't set.
+func pageSize(size int) int {
+	switch {
+	case size <= 0:
+		return DefaultPageSize
+	case size > MaxPageSize:
+		return MaxPageSize
+	}
+
+	return size
+}
+
+// allPages returns URLs of all pages, that are returned by page.
+func allPages(ctx context.Context, page func(ctx context.Context, pageToken string, pageSize int) ([]URL, string, error)) ([]URL, error) {
+	var URLs []URL
+
+	next := ""
+	for {
+		p, nextToken, err := page(ctx, next, MaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		URLs = append(URLs, p...)
+		if nextToken == "" {
+			return URLs, nil
+		}
+		next = nextToken
+	}
+}
+
+// shortCode returns short URL without base URL.
+func shortCode(shortURL string) string {
+	u, err := url.Parse(shortURL)
+	if err != nil || u.Scheme == "" {
+		return shortURL
+	}
+
+	return path.Base(u.Path)
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fe4p3b/url-shortener/internal/app/auth"
+	"github.com/Fe4p3b/url-shortener/internal/app/shortener"
+	"github.com/Fe4p3b/url-shortener/internal/handlers"
+	"github.com/Fe4p3b/url-shortener/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuth issues token "new" for a new user, token of user is its
+// identificator.
+type testAuth struct{}
+
+var _ auth.AuthService = testAuth{}
+
+func (testAuth) CreateUser() (string, error) {
+	return "new", nil
+}
+
+func (testAuth) Encrypt(src string) (string, error) {
+	return src, nil
+}
+
+func (testAuth) Decrypt(src string) ([]byte, error) {
+	return []byte(src), nil
+}
+
+func (testAuth) VerifyUser(user string) error {
+	if user != "new" {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// newTestHandlers returns handlers with memory storage.
+func newTestHandlers() handlers.Handlers {
+	return handlers.NewHandler(shortener.NewShortener(memory.NewMemory(map[string]string{}), "http://localhost:8080"))
+}
+
+// testClient checks calls of client, that behave the same way for
+// every transport.
+func testClient(t *testing.T, c Client) {
+	ctx := context.Background()
+
+	var shortURLs []string
+	for _, u := range []string{"https://yandex.ru", "https://practicum.yandex.ru"} {
+		shortURL, err := c.PostURL(ctx, u, Options{Title: "Yandex"})
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(shortURL, "http://localhost:8080/"))
+		shortURLs = append(shortURLs, shortURL)
+	}
+	assert.NotEmpty(t, c.Token())
+
+	page, next, err := c.GetUserURLsPage(ctx, "", 1)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.NotEmpty(t, next)
+
+	URLs, err := c.GetUserURLs(ctx)
+	require.NoError(t, err)
+	require.Len(t, URLs, 2)
+	for _, u := range URLs {
+		assert.Contains(t, shortURLs, u.ShortURL)
+		assert.Equal(t, "Yandex", u.Title)
+	}
+
+	e, err := c.ExpandURL(ctx, shortURLs[0])
+	require.NoError(t, err)
+	assert.Equal(t, LinkActive, e.State)
+	assert.Equal(t, "https://yandex.ru", e.URL)
+
+	_, err = c.ExpandURL(ctx, "missing")
+	assert.ErrorIs(t, err, ErrorNotFound)
+
+	rules := []TargetingRule{{Platform: PlatformIOS, URL: "https://apps.apple.com"}}
+	require.NoError(t, c.SetTargeting(ctx, shortURLs[0], rules))
+	targeting, err := c.GetTargeting(ctx, shortURLs[0])
+	require.NoError(t, err)
+	assert.Equal(t, rules, targeting)
+
+	variants := []Variant{{Name: "a", URL: "https://a.yandex.ru", Weight: 1}, {Name: "b", URL: "https://b.yandex.ru", Weight: 1}}
+	require.NoError(t, c.SetVariants(ctx, shortURLs[1], variants))
+	stats, err := c.GetVariants(ctx, shortURLs[1])
+	require.NoError(t, err)
+	assert.Equal(t, []VariantStats{{Variant: variants[0]}, {Variant: variants[1]}}, stats)
+
+	_, err = c.GetGeo(ctx, "missing")
+	assert.ErrorIs(t, err, ErrorNotFound)
+
+	assert.NoError(t, c.Ping(ctx))
+	assert.NoError(t, c.DeleteUserURLs(ctx, shortURLs[:1]))
+	assert.NoError(t, c.Close())
+}
+
+func TestConfig_retry(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		name    string
+		retries int
+		errs    []error
+		calls   int
+		wantErr error
+	}{
+		{name: "Test case #1", errs: []error{errTemporary, errTemporary, nil}, calls: 3},
+		{name: "Test case #2", errs: []error{errTemporary, errPermanent, nil}, calls: 2, wantErr: errPermanent},
+		{name: "Test case #3", retries: 1, errs: []error{errTemporary, errTemporary, nil}, calls: 2, wantErr: errTemporary},
+		{name: "Test case #4", retries: -1, errs: []error{errTemporary, nil}, calls: 1, wantErr: errTemporary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Retries: tt.retries, Backoff: time.Millisecond}
+
+			calls := 0
+			err := cfg.retry(context.Background(), func(err error) bool { return err == errTemporary }, func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func Test_shortCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		shortURL string
+		want     string
+	}{
+		{name: "Test case #1", shortURL: "http://localhost:8080/3Hlkv5jvg", want: "3Hlkv5jvg"},
+		{name: "Test case #2", shortURL: "3Hlkv5jvg", want: "3Hlkv5jvg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shortCode(tt.shortURL))
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeToken is a token of user of Fake.
+const FakeToken = "fake"
+
+var (
+	// ErrorInvalidURL is returned by Fake for original URL, that isn't
+	// absolute http or https URL.
+	ErrorInvalidURL = errors.New("invalid URL")
+
+	// ErrorInvalidPageToken is returned by Fake for page token, that
+	// it didn't issue.
+	ErrorInvalidPageToken = errors.New("invalid page token")
+)
+
+// Fake is an in-process client, that keeps short URLs of the only
+// user in memory, it is used in tests of services, that call
+// shortener. Deletion is synchronous and short codes are sequential.
+type Fake struct {
+	mu sync.Mutex
+
+	baseURL string
+	codes   map[string]string
+	links   map[string]*fakeLink
+	clicks  map[string]map[string]uint
+}
+
+// fakeLink is a short URL of Fake.
+type fakeLink struct {
+	URL
+	deleted bool
+}
+
+var _ Client = &Fake{}
+
+// NewFake returns empty fake, whose short URLs start with baseURL.
+func NewFake(baseURL string) *Fake {
+	return &Fake{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		codes:   make(map[string]string),
+		links:   make(map[string]*fakeLink),
+		clicks:  make(map[string]map[string]uint),
+	}
+}
+
+func (f *Fake) PostURL(ctx context.Context, originalURL string, o Options) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.post(originalURL, o)
+}
+
+// post creates short URL, the caller should hold the lock.
+func (f *Fake) post(originalURL string, o Options) (string, error) {
+	u, err := url.Parse(originalURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrorInvalidURL
+	}
+
+	if code, ok := f.codes[originalURL]; ok {
+		return f.baseURL + "/" + code, ErrorConflict
+	}
+
+	code := strconv.Itoa(len(f.links) + 1)
+	f.codes[originalURL] = code
+	f.links[code] = &fakeLink{URL: URL{ShortURL: code, URL: originalURL, CreatedAt: time.Now(), Options: o}}
+
+	return f.baseURL + "/" + code, nil
+}
+
+func (f *Fake) ShortenBatch(ctx context.Context, batch []URL) ([]URL, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]URL, 0, len(batch))
+	for _, u := range batch {
+		shortURL, err := f.post(u.URL, u.Options)
+		if err != nil && !errors.Is(err, ErrorConflict) {
+			return nil, err
+		}
+		result = append(result, URL{CorrelationID: u.CorrelationID, ShortURL: shortURL})
+	}
+
+	return result, nil
+}
+
+func (f *Fake) ExpandURL(ctx context.Context, shortURL string) (*Expansion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	l, ok := f.links[shortCode(shortURL)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	createdAt := l.CreatedAt
+	e := &Expansion{
+		ShortURL:  f.baseURL + "/" + l.ShortURL,
+		Title:     l.Title,
+		CreatedAt: &createdAt,
+		State:     LinkDeleted,
+	}
+	if !l.deleted {
+		e.URL = l.URL.URL
+		e.State = LinkActive
+		e.Flags = l.Flags
+	}
+
+	return e, nil
+}
+
+func (f *Fake) GetUserURLs(ctx context.Context) ([]URL, error) {
+	return allPages(ctx, f.GetUserURLsPage)
+}
+
+func (f *Fake) GetUserURLsPage(ctx context.Context, pageToken string, size int) ([]URL, string, error) {
+	after, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var codes []string
+	for code, l := range f.links {
+		if !l.deleted && code > after {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	next := ""
+	if size = pageSize(size); len(codes) > size {
+		codes = codes[:size]
+		next = encodePageToken(codes[size-1])
+	}
+
+	URLs := make([]URL, 0, len(codes))
+	for _, code := range codes {
+		u := f.links[code].URL
+		u.ShortURL = f.baseURL + "/" + code
+		URLs = append(URLs, u)
+	}
+
+	return URLs, next, nil
+}
+
+func (f *Fake) DeleteUserURLs(ctx context.Context, URLs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, u := range URLs {
+		if l, ok := f.links[shortCode(u)]; ok {
+			l.deleted = true
+		}
+	}
+
+	return nil
+}
+
+func (f *Fake) GetTargeting(ctx context.Context, shortURL string) ([]TargetingRule, error) {
+	var rules []TargetingRule
+	err := f.options(shortURL, func(o *Options) { rules = o.Targeting })
+
+	return rules, err
+}
+
+func (f *Fake) SetTargeting(ctx context.Context, shortURL string, rules []TargetingRule) error {
+	return f.options(shortURL, func(o *Options) { o.Targeting = rules })
+}
+
+func (f *Fake) GetGeo(ctx context.Context, shortURL string) ([]GeoRule, error) {
+	var rules []GeoRule
+	err := f.options(shortURL, func(o *Options) { rules = o.Geo })
+
+	return rules, err
+}
+
+func (f *Fake) SetGeo(ctx context.Context, shortURL string, rules []GeoRule) error {
+	return f.options(shortURL, func(o *Options) { o.Geo = rules })
+}
+
+func (f *Fake) GetVariants(ctx context.Context, shortURL string) ([]VariantStats, error) {
+	var variants []Variant
+	if err := f.options(shortURL, func(o *Options) { variants = o.Variants }); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := make([]VariantStats, 0, len(variants))
+	for _, v := range variants {
+		stats = append(stats, VariantStats{Variant: v, Clicks: f.clicks[shortCode(shortURL)][v.Name]})
+	}
+
+	return stats, nil
+}
+
+func (f *Fake) SetVariants(ctx context.Context, shortURL string, variants []Variant) error {
+	return f.options(shortURL, func(o *Options) { o.Variants = variants })
+}
+
+// Click counts visit of short URL, that was served variant, it is
+// reported by GetVariants.
+func (f *Fake) Click(shortURL string, variant string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code := shortCode(shortURL)
+	if f.clicks[code] == nil {
+		f.clicks[code] = make(map[string]uint)
+	}
+	f.clicks[code][variant]++
+}
+
+// Flag sets flags of short URL, like service does for risky
+// destinations.
+func (f *Fake) Flag(shortURL string, flags ...string) error {
+	return f.options(shortURL, func(o *Options) { o.Flags = flags })
+}
+
+func (f *Fake) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (f *Fake) GetStats(ctx context.Context) (*Stats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := &Stats{Users: 1}
+	for _, l := range f.links {
+		if !l.deleted {
+			stats.URLs++
+		}
+	}
+
+	return stats, nil
+}
+
+func (f *Fake) Token() string {
+	return FakeToken
+}
+
+func (f *Fake) Close() error {
+	return nil
+}
+
+// options calls fn with options of short URL, that isn't deleted.
+func (f *Fake) options(shortURL string, fn func(o *Options)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	l, ok := f.links[shortCode(shortURL)]
+	if !ok || l.deleted {
+		return ErrorNotFound
+	}
+
+	fn(&l.Options)
+	return nil
+}
+
+// encodePageToken returns page token of page, that goes after short
+// URL, like server does.
+func encodePageToken(after string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(after))
+}
+
+// decodePageToken returns short URL, that page of page token goes
+// after.
+func decodePageToken(token string) (string, error) {
+	after, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrorInvalidPageToken, err)
+	}
+
+	return string(after), nil
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	testClient(t, NewFake("http://localhost:8080"))
+}
+
+func TestFake_PostURL(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake("http://localhost:8080/")
+
+	shortURL, err := f.PostURL(ctx, "https://yandex.ru", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/1", shortURL)
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr error
+	}{
+		{name: "Test case #1", url: "https://yandex.ru", want: "http://localhost:8080/1", wantErr: ErrorConflict},
+		{name: "Test case #2", url: "yandex.ru", wantErr: ErrorInvalidURL},
+		{name: "Test case #3", url: "https://practicum.yandex.ru", want: "http://localhost:8080/2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.PostURL(ctx, tt.url, Options{})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFake_DeleteUserURLs(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake("http://localhost:8080")
+
+	batch, err := f.ShortenBatch(ctx, []URL{
+		{CorrelationID: "a", URL: "https://yandex.ru"},
+		{CorrelationID: "b", URL: "https://practicum.yandex.ru"},
+	})
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	require.NoError(t, f.DeleteUserURLs(ctx, []string{batch[0].ShortURL}))
+
+	e, err := f.ExpandURL(ctx, batch[0].ShortURL)
+	require.NoError(t, err)
+	assert.Equal(t, LinkDeleted, e.State)
+	assert.Empty(t, e.URL)
+
+	URLs, err := f.GetUserURLs(ctx)
+	require.NoError(t, err)
+	require.Len(t, URLs, 1)
+	assert.Equal(t, batch[1].ShortURL, URLs[0].ShortURL)
+
+	_, err = f.GetTargeting(ctx, batch[0].ShortURL)
+	assert.ErrorIs(t, err, ErrorNotFound)
+
+	stats, err := f.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{URLs: 1, Users: 1}, stats)
+}
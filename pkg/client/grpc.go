@@ -0,0 +1,430 @@
+package client
+
+import (
+	"context"
+
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// queryPolicies maps query policies to query policies of v2 API.
+var queryPolicies = map[QueryPolicy]pbv2.QueryPolicy{
+	"":            pbv2.QueryPolicy_QUERY_POLICY_UNSPECIFIED,
+	QueryDrop:     pbv2.QueryPolicy_QUERY_POLICY_DROP,
+	QueryKeep:     pbv2.QueryPolicy_QUERY_POLICY_KEEP,
+	QueryOverride: pbv2.QueryPolicy_QUERY_POLICY_OVERRIDE,
+	QueryAppend:   pbv2.QueryPolicy_QUERY_POLICY_APPEND,
+}
+
+// linkStates maps states of v2 API to states of short URLs.
+var linkStates = map[pbv2.LinkState]LinkState{
+	pbv2.LinkState_LINK_STATE_ACTIVE:   LinkActive,
+	pbv2.LinkState_LINK_STATE_DELETED:  LinkDeleted,
+	pbv2.LinkState_LINK_STATE_DISABLED: LinkDisabled,
+}
+
+// GRPCClient calls shortener.v2 API over gRPC, token of user is passed
+// in metadata.
+type GRPCClient struct {
+	cfg    Config
+	conn   *grpc.ClientConn
+	c      pbv2.ShortenerClient
+	health healthpb.HealthClient
+	token  tokenStore
+}
+
+var _ Client = &GRPCClient{}
+
+// NewGRPCClient returns client of server at cfg.Address, connection
+// is established lazily on the first call.
+func NewGRPCClient(cfg Config) (*GRPCClient, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		creds = credentials.NewTLS(cfg.TLS)
+	}
+
+	conn, err := grpc.Dial(cfg.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	return newGRPCClient(conn, cfg), nil
+}
+
+// newGRPCClient returns client, that calls server by connection.
+func newGRPCClient(conn *grpc.ClientConn, cfg Config) *GRPCClient {
+	c := &GRPCClient{
+		cfg:    cfg,
+		conn:   conn,
+		c:      pbv2.NewShortenerClient(conn),
+		health: healthpb.NewHealthClient(conn),
+	}
+	c.token.set(cfg.Token)
+
+	return c
+}
+
+func (c *GRPCClient) PostURL(ctx context.Context, originalURL string, o Options) (string, error) {
+	var header metadata.MD
+	r, err := c.c.CreateURL(c.context(ctx), &pbv2.CreateURLRequest{
+		OriginalUrl: originalURL,
+		Options:     optionsToV2(o),
+	}, grpc.Header(&header))
+	c.setToken(header)
+	if err != nil {
+		return conflictShortURL(err), statusError(err)
+	}
+
+	return r.ShortUrl, nil
+}
+
+func (c *GRPCClient) ShortenBatch(ctx context.Context, batch []URL) ([]URL, error) {
+	in := &pbv2.BatchCreateURLsRequest{}
+	for _, u := range batch {
+		in.Entries = append(in.Entries, &pbv2.BatchCreateURLsRequest_Entry{
+			CorrelationId: u.CorrelationID,
+			OriginalUrl:   u.URL,
+			Options:       optionsToV2(u.Options),
+		})
+	}
+
+	var header metadata.MD
+	r, err := c.c.BatchCreateURLs(c.context(ctx), in, grpc.Header(&header))
+	c.setToken(header)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	result := make([]URL, 0, len(r.Results))
+	for _, v := range r.Results {
+		result = append(result, URL{CorrelationID: v.CorrelationId, ShortURL: v.ShortUrl})
+	}
+
+	return result, nil
+}
+
+func (c *GRPCClient) ExpandURL(ctx context.Context, shortURL string) (*Expansion, error) {
+	var r *pbv2.ExpandURLResponse
+	err := c.retry(ctx, func() (err error) {
+		r, err = c.c.ExpandURL(c.context(ctx), &pbv2.ExpandURLRequest{ShortUrl: shortCode(shortURL)})
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return expansionFromV2(r), nil
+}
+
+func (c *GRPCClient) GetUserURLs(ctx context.Context) ([]URL, error) {
+	return allPages(ctx, c.GetUserURLsPage)
+}
+
+func (c *GRPCClient) GetUserURLsPage(ctx context.Context, pageToken string, size int) ([]URL, string, error) {
+	var r *pbv2.ListURLsResponse
+	err := c.retry(ctx, func() (err error) {
+		var header metadata.MD
+		r, err = c.c.ListURLs(c.context(ctx), &pbv2.ListURLsRequest{PageSize: int32(pageSize(size)), PageToken: pageToken}, grpc.Header(&header))
+		c.setToken(header)
+		return err
+	})
+	if err != nil {
+		return nil, "", statusError(err)
+	}
+
+	URLs := make([]URL, 0, len(r.Links))
+	for _, l := range r.Links {
+		URLs = append(URLs, linkFromV2(l))
+	}
+
+	return URLs, r.NextPageToken, nil
+}
+
+func (c *GRPCClient) DeleteUserURLs(ctx context.Context, URLs []string) error {
+	shortURLs := make([]string, 0, len(URLs))
+	for _, u := range URLs {
+		shortURLs = append(shortURLs, shortCode(u))
+	}
+
+	err := c.retry(ctx, func() error {
+		var header metadata.MD
+		_, err := c.c.DeleteURLs(c.context(ctx), &pbv2.DeleteURLsRequest{ShortUrls: shortURLs}, grpc.Header(&header))
+		c.setToken(header)
+		return err
+	})
+
+	return statusError(err)
+}
+
+func (c *GRPCClient) GetTargeting(ctx context.Context, shortURL string) ([]TargetingRule, error) {
+	o, err := c.getOptions(ctx, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Targeting, nil
+}
+
+func (c *GRPCClient) SetTargeting(ctx context.Context, shortURL string, rules []TargetingRule) error {
+	return c.updateOptions(ctx, shortURL, "targeting", Options{Targeting: rules})
+}
+
+func (c *GRPCClient) GetGeo(ctx context.Context, shortURL string) ([]GeoRule, error) {
+	o, err := c.getOptions(ctx, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Geo, nil
+}
+
+func (c *GRPCClient) SetGeo(ctx context.Context, shortURL string, rules []GeoRule) error {
+	return c.updateOptions(ctx, shortURL, "geo", Options{Geo: rules})
+}
+
+func (c *GRPCClient) GetVariants(ctx context.Context, shortURL string) ([]VariantStats, error) {
+	var r *pbv2.GetVariantStatsResponse
+	err := c.retry(ctx, func() (err error) {
+		r, err = c.c.GetVariantStats(c.context(ctx), &pbv2.GetVariantStatsRequest{ShortUrl: shortCode(shortURL)})
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	variants := make([]VariantStats, 0, len(r.Stats))
+	for _, s := range r.Stats {
+		variants = append(variants, VariantStats{
+			Variant: Variant{Name: s.Variant.GetName(), URL: s.Variant.GetUrl(), Weight: uint(s.Variant.GetWeight())},
+			Clicks:  uint(s.Clicks),
+		})
+	}
+
+	return variants, nil
+}
+
+func (c *GRPCClient) SetVariants(ctx context.Context, shortURL string, variants []Variant) error {
+	return c.updateOptions(ctx, shortURL, "variants", Options{Variants: variants})
+}
+
+// Ping checks health of shortener.v2.Shortener service.
+func (c *GRPCClient) Ping(ctx context.Context) error {
+	var r *healthpb.HealthCheckResponse
+	err := c.retry(ctx, func() (err error) {
+		r, err = c.health.Check(ctx, &healthpb.HealthCheckRequest{Service: pbv2.Shortener_ServiceDesc.ServiceName})
+		return err
+	})
+	if err != nil {
+		return statusError(err)
+	}
+
+	if r.Status != healthpb.HealthCheckResponse_SERVING {
+		return status.Errorf(codes.Unavailable, "service is %s", r.Status)
+	}
+
+	return nil
+}
+
+func (c *GRPCClient) GetStats(ctx context.Context) (*Stats, error) {
+	var r *pbv2.GetStatsResponse
+	err := c.retry(ctx, func() (err error) {
+		r, err = c.c.GetStats(c.context(ctx), &empty.Empty{})
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &Stats{URLs: uint(r.Urls), Users: uint(r.Users)}, nil
+}
+
+func (c *GRPCClient) Token() string {
+	return c.token.get()
+}
+
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *GRPCClient) getOptions(ctx context.Context, shortURL string) (Options, error) {
+	var r *pbv2.LinkOptions
+	err := c.retry(ctx, func() (err error) {
+		r, err = c.c.GetURLOptions(c.context(ctx), &pbv2.GetURLOptionsRequest{ShortUrl: shortCode(shortURL)})
+		return err
+	})
+	if err != nil {
+		return Options{}, statusError(err)
+	}
+
+	return optionsFromV2(r), nil
+}
+
+// updateOptions replaces field of options of user's short URL by
+// field of o.
+func (c *GRPCClient) updateOptions(ctx context.Context, shortURL string, field string, o Options) error {
+	err := c.retry(ctx, func() error {
+		_, err := c.c.UpdateURLOptions(c.context(ctx), &pbv2.UpdateURLOptionsRequest{
+			ShortUrl:   shortCode(shortURL),
+			Options:    optionsToV2(o),
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{field}},
+		})
+		return err
+	})
+
+	return statusError(err)
+}
+
+// retry retries fn, while server is unavailable.
+func (c *GRPCClient) retry(ctx context.Context, fn func() error) error {
+	return c.cfg.retry(ctx, func(err error) bool {
+		return ctx.Err() == nil && status.Code(err) == codes.Unavailable
+	}, fn)
+}
+
+// context returns context with token of user in metadata.
+func (c *GRPCClient) context(ctx context.Context) context.Context {
+	token := c.token.get()
+	if token == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, TokenKey, token)
+}
+
+// setToken keeps token, that server issued for new user.
+func (c *GRPCClient) setToken(header metadata.MD) {
+	if token := header.Get(TokenKey); len(token) > 0 {
+		c.token.set(token[0])
+	}
+}
+
+// statusError returns ErrorNotFound, ErrorConflict or ErrorGone for
+// status of err, other errors are returned as is.
+func statusError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+
+	switch s.Code() {
+	case codes.NotFound:
+		return ErrorNotFound
+	case codes.AlreadyExists:
+		return ErrorConflict
+	case codes.FailedPrecondition:
+		for _, d := range s.Details() {
+			if _, ok := d.(*errdetails.PreconditionFailure); ok {
+				return ErrorGone
+			}
+		}
+	}
+
+	return err
+}
+
+// conflictShortURL returns existing short URL, that is reported by
+// details of conflict.
+func conflictShortURL(err error) string {
+	if status.Code(err) != codes.AlreadyExists {
+		return ""
+	}
+
+	for _, d := range status.Convert(err).Details() {
+		if r, ok := d.(*errdetails.ResourceInfo); ok {
+			return r.ResourceName
+		}
+	}
+
+	return ""
+}
+
+// optionsToV2 returns v2 API message of options.
+func optionsToV2(o Options) *pbv2.LinkOptions {
+	result := &pbv2.LinkOptions{
+		Title:       o.Title,
+		QueryPolicy: queryPolicies[o.QueryPolicy],
+		Utm:         o.UTM,
+	}
+	for _, r := range o.Targeting {
+		result.Targeting = append(result.Targeting, &pbv2.TargetingRule{Platform: r.Platform, Language: r.Language, Url: r.URL})
+	}
+	for _, r := range o.Geo {
+		result.Geo = append(result.Geo, &pbv2.GeoRule{Countries: r.Countries, Url: r.URL})
+	}
+	for _, v := range o.Variants {
+		result.Variants = append(result.Variants, &pbv2.Variant{Name: v.Name, Url: v.URL, Weight: uint32(v.Weight)})
+	}
+
+	return result
+}
+
+// optionsFromV2 returns options of v2 API message, nil message is
+// empty options.
+func optionsFromV2(o *pbv2.LinkOptions) Options {
+	if o == nil {
+		return Options{}
+	}
+
+	result := Options{
+		Title: o.Title,
+		UTM:   o.Utm,
+	}
+	for qp, p := range queryPolicies {
+		if p == o.QueryPolicy {
+			result.QueryPolicy = qp
+		}
+	}
+	for _, r := range o.Targeting {
+		result.Targeting = append(result.Targeting, TargetingRule{Platform: r.Platform, Language: r.Language, URL: r.Url})
+	}
+	for _, r := range o.Geo {
+		result.Geo = append(result.Geo, GeoRule{Countries: r.Countries, URL: r.Url})
+	}
+	for _, v := range o.Variants {
+		result.Variants = append(result.Variants, Variant{Name: v.Name, URL: v.Url, Weight: uint(v.Weight)})
+	}
+
+	return result
+}
+
+// linkFromV2 returns URL of user of v2 API message.
+func linkFromV2(l *pbv2.Link) URL {
+	u := URL{
+		ShortURL: l.ShortUrl,
+		URL:      l.OriginalUrl,
+		Options:  optionsFromV2(l.Options),
+	}
+	u.Flags = l.Flags
+	if l.CreateTime != nil {
+		u.CreatedAt = l.CreateTime.AsTime()
+	}
+
+	return u
+}
+
+// expansionFromV2 returns expansion of v2 API message.
+func expansionFromV2(r *pbv2.ExpandURLResponse) *Expansion {
+	e := &Expansion{
+		ShortURL: r.ShortUrl,
+		URL:      r.OriginalUrl,
+		Title:    r.Title,
+		State:    linkStates[r.State],
+		Flags:    r.Flags,
+		Reason:   r.Reason,
+	}
+	if r.CreateTime != nil {
+		createdAt := r.CreateTime.AsTime()
+		e.CreatedAt = &createdAt
+	}
+
+	return e
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	grpcHandler "github.com/Fe4p3b/url-shortener/internal/handlers/grpc"
+	pbv2 "github.com/Fe4p3b/url-shortener/internal/proto/shortener/v2"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCClient returns client of server s, that is served by
+// in-memory listener.
+func newTestGRPCClient(t *testing.T, s *grpc.Server, cfg Config) *GRPCClient {
+	listen := bufconn.Listen(1 << 20)
+	go s.Serve(listen)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listen.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return newGRPCClient(conn, cfg)
+}
+
+func TestGRPCClient(t *testing.T) {
+	h := newTestHandlers()
+
+	a := grpcHandler.NewAuthInterceptor(testAuth{})
+	s := grpc.NewServer(grpc.UnaryInterceptor(a.Unary), grpc.StreamInterceptor(a.Stream))
+	pbv2.RegisterShortenerServer(s, grpcHandler.NewShortenerV2Server(h))
+
+	health := grpcHandler.NewHealthChecker(h, grpcHandler.DefaultHealthInterval)
+	health.Update()
+	healthpb.RegisterHealthServer(s, health)
+
+	testClient(t, newTestGRPCClient(t, s, Config{}))
+}
+
+// flakyServer fails calls with errors in order, then succeeds.
+type flakyServer struct {
+	pbv2.UnimplementedShortenerServer
+	errs  []error
+	calls int
+}
+
+func (s *flakyServer) GetStats(ctx context.Context, in *empty.Empty) (*pbv2.GetStatsResponse, error) {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return nil, s.errs[s.calls-1]
+	}
+	return &pbv2.GetStatsResponse{Urls: 1, Users: 1}, nil
+}
+
+func (s *flakyServer) CreateURL(ctx context.Context, in *pbv2.CreateURLRequest) (*pbv2.CreateURLResponse, error) {
+	s.calls++
+	return nil, s.errs[s.calls-1]
+}
+
+func TestGRPCClient_GetStats(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		name    string
+		errs    []error
+		calls   int
+		wantErr bool
+	}{
+		{name: "Test case #1", errs: []error{unavailable, unavailable}, calls: 3},
+		{name: "Test case #2", errs: []error{status.Error(codes.Internal, "internal error")}, calls: 1, wantErr: true},
+		{name: "Test case #3", errs: []error{unavailable, unavailable, unavailable, unavailable}, calls: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &flakyServer{errs: tt.errs}
+			s := grpc.NewServer()
+			pbv2.RegisterShortenerServer(s, fs)
+
+			c := newTestGRPCClient(t, s, Config{Backoff: time.Millisecond})
+			stats, err := c.GetStats(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &Stats{URLs: 1, Users: 1}, stats)
+			}
+			assert.Equal(t, tt.calls, fs.calls)
+		})
+	}
+}
+
+func TestGRPCClient_PostURL(t *testing.T) {
+	conflict, err := status.New(codes.AlreadyExists, "URL already exists").WithDetails(&errdetails.ResourceInfo{ResourceName: "http://localhost:8080/a"})
+	require.NoError(t, err)
+	gone, err := status.New(codes.FailedPrecondition, "URL is gone").WithDetails(&errdetails.PreconditionFailure{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		err     error
+		want    string
+		wantErr error
+	}{
+		{name: "Test case #1", err: conflict.Err(), want: "http://localhost:8080/a", wantErr: ErrorConflict},
+		{name: "Test case #2", err: gone.Err(), wantErr: ErrorGone},
+		{name: "Test case #3", err: status.Error(codes.NotFound, "link not found"), wantErr: ErrorNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &flakyServer{errs: []error{tt.err}}
+			s := grpc.NewServer()
+			pbv2.RegisterShortenerServer(s, fs)
+
+			c := newTestGRPCClient(t, s, Config{Backoff: time.Millisecond})
+			shortURL, err := c.PostURL(context.Background(), "https://yandex.ru", Options{})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, shortURL)
+			assert.Equal(t, 1, fs.calls)
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HTTPError is returned, when server responds with unexpected status.
+type HTTPError struct {
+	StatusCode int
+
+	// Message is a beginning of body of response.
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// HTTPClient calls JSON API of shortener over HTTP, token of user is
+// passed in a cookie.
+type HTTPClient struct {
+	cfg     Config
+	baseURL string
+	c       *http.Client
+	token   tokenStore
+}
+
+var _ Client = &HTTPClient{}
+
+// NewHTTPClient returns client of server at cfg.Address, scheme of
+// address is "https", if TLS is configured, and "http" otherwise,
+// unless it is set.
+func NewHTTPClient(cfg Config) *HTTPClient {
+	baseURL := cfg.Address
+	if !strings.Contains(baseURL, "://") {
+		scheme := "http://"
+		if cfg.TLS != nil {
+			scheme = "https://"
+		}
+		baseURL = scheme + baseURL
+	}
+
+	c := &http.Client{
+		Timeout: cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if cfg.TLS != nil {
+		c.Transport = &http.Transport{TLSClientConfig: cfg.TLS}
+	}
+
+	client := &HTTPClient{cfg: cfg, baseURL: strings.TrimSuffix(baseURL, "/"), c: c}
+	client.token.set(cfg.Token)
+
+	return client
+}
+
+func (c *HTTPClient) PostURL(ctx context.Context, originalURL string, o Options) (string, error) {
+	var result shortenResponse
+	code, err := c.do(ctx, http.MethodPost, "/api/shorten", &shortenRequest{URL: originalURL, Options: o}, &result, http.StatusCreated, http.StatusConflict)
+	if err != nil {
+		return "", err
+	}
+
+	if code == http.StatusConflict {
+		return result.ShortURL, ErrorConflict
+	}
+
+	return result.ShortURL, nil
+}
+
+func (c *HTTPClient) ShortenBatch(ctx context.Context, batch []URL) ([]URL, error) {
+	var result []URL
+	if _, err := c.do(ctx, http.MethodPost, "/api/shorten/batch", batch, &result, http.StatusCreated); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *HTTPClient) ExpandURL(ctx context.Context, shortURL string) (*Expansion, error) {
+	e := &Expansion{}
+	err := c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodGet, "/api/expand/"+url.PathEscape(shortCode(shortURL)), nil, e, http.StatusOK, http.StatusGone)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (c *HTTPClient) GetUserURLs(ctx context.Context) ([]URL, error) {
+	return allPages(ctx, c.GetUserURLsPage)
+}
+
+func (c *HTTPClient) GetUserURLsPage(ctx context.Context, pageToken string, size int) ([]URL, string, error) {
+	q := url.Values{}
+	q.Set("page_size", strconv.Itoa(pageSize(size)))
+	q.Set("page_token", pageToken)
+
+	var URLs []URL
+	var next string
+	err := c.retry(ctx, func() error {
+		resp, err := c.request(ctx, http.MethodGet, "/user/urls?"+q.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		next = resp.Header.Get(NextPageTokenHeader)
+		return decode(resp, &URLs, http.StatusOK)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return URLs, next, nil
+}
+
+func (c *HTTPClient) DeleteUserURLs(ctx context.Context, URLs []string) error {
+	shortURLs := make([]string, 0, len(URLs))
+	for _, u := range URLs {
+		shortURLs = append(shortURLs, shortCode(u))
+	}
+
+	return c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodDelete, "/api/user/urls", shortURLs, nil, http.StatusAccepted)
+		return err
+	})
+}
+
+func (c *HTTPClient) GetTargeting(ctx context.Context, shortURL string) ([]TargetingRule, error) {
+	var rules []TargetingRule
+	if err := c.getOptions(ctx, shortURL, "targeting", &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (c *HTTPClient) SetTargeting(ctx context.Context, shortURL string, rules []TargetingRule) error {
+	if rules == nil {
+		rules = []TargetingRule{}
+	}
+
+	return c.setOptions(ctx, shortURL, "targeting", rules)
+}
+
+func (c *HTTPClient) GetGeo(ctx context.Context, shortURL string) ([]GeoRule, error) {
+	var rules []GeoRule
+	if err := c.getOptions(ctx, shortURL, "geo", &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (c *HTTPClient) SetGeo(ctx context.Context, shortURL string, rules []GeoRule) error {
+	if rules == nil {
+		rules = []GeoRule{}
+	}
+
+	return c.setOptions(ctx, shortURL, "geo", rules)
+}
+
+func (c *HTTPClient) GetVariants(ctx context.Context, shortURL string) ([]VariantStats, error) {
+	var variants []VariantStats
+	if err := c.getOptions(ctx, shortURL, "variants", &variants); err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+func (c *HTTPClient) SetVariants(ctx context.Context, shortURL string, variants []Variant) error {
+	if variants == nil {
+		variants = []Variant{}
+	}
+
+	return c.setOptions(ctx, shortURL, "variants", variants)
+}
+
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	return c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodGet, "/ping", nil, nil, http.StatusOK)
+		return err
+	})
+}
+
+func (c *HTTPClient) GetStats(ctx context.Context) (*Stats, error) {
+	stats := &Stats{}
+	err := c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodGet, "/api/internal/stats", nil, stats, http.StatusOK)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (c *HTTPClient) Token() string {
+	return c.token.get()
+}
+
+func (c *HTTPClient) Close() error {
+	c.c.CloseIdleConnections()
+	return nil
+}
+
+// getOptions decodes option of user's short URL into result.
+func (c *HTTPClient) getOptions(ctx context.Context, shortURL string, option string, result interface{}) error {
+	return c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodGet, optionsPath(shortURL, option), nil, result, http.StatusOK)
+		return err
+	})
+}
+
+// setOptions replaces option of user's short URL by value.
+func (c *HTTPClient) setOptions(ctx context.Context, shortURL string, option string, value interface{}) error {
+	return c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodPut, optionsPath(shortURL, option), value, nil, http.StatusNoContent)
+		return err
+	})
+}
+
+func optionsPath(shortURL string, option string) string {
+	return "/api/user/urls/" + url.PathEscape(shortCode(shortURL)) + "/" + option
+}
+
+// retry retries fn on network errors and on statuses, that report
+// temporary unavailability of server.
+func (c *HTTPClient) retry(ctx context.Context, fn func() error) error {
+	return c.cfg.retry(ctx, func(err error) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		e, ok := err.(*HTTPError)
+		if !ok {
+			_, ok = err.(*url.Error)
+			return ok
+		}
+
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}, fn)
+}
+
+// do sends body in json and decodes response into result, if status
+// of response is one of codes, and returns status.
+func (c *HTTPClient) do(ctx context.Context, method string, path string, body interface{}, result interface{}, codes ...int) (int, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	resp, err := c.request(ctx, method, path, r)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, decode(resp, result, codes...)
+}
+
+// request sends request with token of user in a cookie and keeps
+// token, that server issued for new user.
+func (c *HTTPClient) request(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.token.get(); token != "" {
+		req.AddCookie(&http.Cookie{Name: TokenKey, Value: token})
+	}
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == TokenKey {
+			c.token.set(cookie.Value)
+		}
+	}
+
+	return resp, nil
+}
+
+// decode decodes json response into result, if status of response is
+// one of codes, otherwise error of status is returned.
+func decode(resp *http.Response, result interface{}, codes ...int) error {
+	for _, code := range codes {
+		if resp.StatusCode != code {
+			continue
+		}
+
+		if result == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return ErrorNotFound
+	case http.StatusGone:
+		return ErrorGone
+	case http.StatusConflict:
+		return ErrorConflict
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &HTTPError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(b))}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpHandler "github.com/Fe4p3b/url-shortener/internal/handlers/http"
+	"github.com/Fe4p3b/url-shortener/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient(t *testing.T) {
+	h := httpHandler.NewHandler(newTestHandlers())
+	h.SetupAPIRouting()
+
+	s := httptest.NewServer(middleware.NewAuthMiddleware(testAuth{}).Middleware(h.Router))
+	defer s.Close()
+
+	testClient(t, NewHTTPClient(Config{Address: s.URL}))
+}
+
+func TestHTTPClient_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		body     string
+		want     string
+		wantErr  error
+		calls    int
+	}{
+		{name: "Test case #1", statuses: []int{http.StatusServiceUnavailable, http.StatusCreated}, body: `{"result":"http://localhost:8080/a"}`, calls: 1, wantErr: &HTTPError{StatusCode: http.StatusServiceUnavailable, Message: `{"result":"http://localhost:8080/a"}`}},
+		{name: "Test case #2", statuses: []int{http.StatusConflict}, body: `{"result":"http://localhost:8080/a"}`, want: "http://localhost:8080/a", calls: 1, wantErr: ErrorConflict},
+		{name: "Test case #3", statuses: []int{http.StatusGone}, calls: 1, wantErr: ErrorGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				w.Write([]byte(tt.body))
+				calls++
+			}))
+			defer s.Close()
+
+			c := NewHTTPClient(Config{Address: s.URL, Backoff: time.Millisecond})
+			shortURL, err := c.PostURL(context.Background(), "https://yandex.ru", Options{})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, shortURL)
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func TestHTTPClient_retry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		calls      int
+		wantErr    error
+		wantStatus int
+	}{
+		{name: "Test case #1", statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, calls: 3},
+		{name: "Test case #2", statuses: []int{http.StatusNotFound, http.StatusOK}, calls: 1, wantErr: ErrorNotFound},
+		{name: "Test case #3", statuses: []int{http.StatusInternalServerError, http.StatusOK}, calls: 1, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: TokenKey, Value: "issued"})
+				w.WriteHeader(tt.statuses[calls])
+				w.Write([]byte(`{"short_url":"http://localhost:8080/a","state":"active"}`))
+				calls++
+			}))
+			defer s.Close()
+
+			c := NewHTTPClient(Config{Address: s.URL, Backoff: time.Millisecond})
+			e, err := c.ExpandURL(context.Background(), "a")
+			switch {
+			case tt.wantStatus != 0:
+				var httpErr *HTTPError
+				require.ErrorAs(t, err, &httpErr)
+				assert.Equal(t, tt.wantStatus, httpErr.StatusCode)
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "http://localhost:8080/a", e.ShortURL)
+			}
+			assert.Equal(t, tt.calls, calls)
+			assert.Equal(t, "issued", c.Token())
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package client
+
+import "time"
+
+// QueryPolicy defines how query of a short URL visit is passed to
+// original URL.
+type QueryPolicy string
+
+const (
+	// QueryDrop drops query of a visit, it is used by default.
+	QueryDrop QueryPolicy = "drop"
+
+	// QueryKeep merges query of a visit into original URL, on
+	// conflict the value of original URL is kept.
+	QueryKeep QueryPolicy = "keep"
+
+	// QueryOverride merges query of a visit into original URL, on
+	// conflict the value of a visit replaces the value of original URL.
+	QueryOverride QueryPolicy = "override"
+
+	// QueryAppend merges query of a visit into original URL, on
+	// conflict both values are kept.
+	QueryAppend QueryPolicy = "append"
+)
+
+// Platforms of visitors, that are matched by targeting rules.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+	PlatformOther   = "other"
+)
+
+// TargetingRule redirects visitors, that match platform and language
+// of the rule, to URL. Empty platform or language matches any visitor.
+type TargetingRule struct {
+	// Platform is one of Platform constants.
+	Platform string `json:"platform,omitempty"`
+
+	// Language is a language tag, like "en" or "en-US", that visitor
+	// accepts.
+	Language string `json:"language,omitempty"`
+
+	// URL is a destination for matched visitors.
+	URL string `json:"url"`
+}
+
+// GeoRule redirects visitors from countries of the rule to URL.
+type GeoRule struct {
+	// Countries are ISO 3166-1 alpha-2 codes, like "KZ".
+	Countries []string `json:"countries"`
+
+	// URL is a destination for matched visitors.
+	URL string `json:"url"`
+}
+
+// Variant is one of destinations of short URL, that are rotated by
+// weight.
+type Variant struct {
+	// Name identifies variant in analytics.
+	Name string `json:"name"`
+
+	// URL is a destination of variant.
+	URL string `json:"url"`
+
+	// Weight is a relative share of visits, that are served the variant.
+	Weight uint `json:"weight"`
+}
+
+// VariantStats is a variant with number of visits, that it was served.
+type VariantStats struct {
+	Variant
+
+	Clicks uint `json:"clicks"`
+}
+
+// Options are optional settings of short URL, that are used on
+// redirect.
+type Options struct {
+	// Title is a title of short URL, that is shown on preview page.
+	Title string `json:"title,omitempty"`
+
+	// QueryPolicy defines how query of a visit is passed to original URL.
+	QueryPolicy QueryPolicy `json:"query_policy,omitempty"`
+
+	// UTM is a set of UTM parameter templates, that are appended to
+	// original URL on redirect.
+	UTM map[string]string `json:"utm,omitempty"`
+
+	// Targeting are rules by platform and language of visitor.
+	Targeting []TargetingRule `json:"targeting,omitempty"`
+
+	// Geo are rules by country of visitor.
+	Geo []GeoRule `json:"geo,omitempty"`
+
+	// Variants are destinations, that are rotated by weight.
+	Variants []Variant `json:"variants,omitempty"`
+
+	// Flags are warnings about destinations, that are set by service,
+	// they are ignored, when short URL is created.
+	Flags []string `json:"flags,omitempty"`
+}
+
+// URL is a short URL of user or an entry of batch.
+type URL struct {
+	// CorrelationID matches entry of batch with its result.
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// URL is original URL.
+	URL string `json:"original_url,omitempty"`
+
+	ShortURL string `json:"short_url,omitempty"`
+
+	// CreatedAt is a time of creation of short URL, it is zero, if
+	// server doesn't report it.
+	CreatedAt time.Time `json:"-"`
+
+	Options
+}
+
+// LinkState is a state of short URL, that is reported on expansion.
+type LinkState string
+
+const (
+	// LinkActive is a state of short URL, that redirects visitors.
+	LinkActive LinkState = "active"
+
+	// LinkDeleted is a state of short URL, that was deleted by owner.
+	LinkDeleted LinkState = "deleted"
+
+	// LinkDisabled is a state of short URL, that was disabled by
+	// policy of service.
+	LinkDisabled LinkState = "disabled"
+)
+
+// Expansion describes where short URL leads.
+type Expansion struct {
+	// ShortURL is short URL with base URL.
+	ShortURL string `json:"short_url"`
+
+	// URL is original URL, it is empty, if short URL is not active.
+	URL string `json:"url,omitempty"`
+
+	// Title is a title of short URL, that is set by owner.
+	Title string `json:"title,omitempty"`
+
+	// CreatedAt is a time of creation of short URL, it is nil, if
+	// server doesn't know it.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	State LinkState `json:"state"`
+
+	// Flags are warnings about destinations of active short URL.
+	Flags []string `json:"flags,omitempty"`
+
+	// Reason is a reason, why short URL was disabled.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Stats are statistics of service.
+type Stats struct {
+	URLs  uint `json:"urls"`
+	Users uint `json:"users"`
+}
+
+// shortenRequest is a body of request of /api/shorten.
+type shortenRequest struct {
+	URL string `json:"url"`
+
+	Options
+}
+
+// shortenResponse is a body of response of /api/shorten.
+type shortenResponse struct {
+	ShortURL string `json:"result"`
+}
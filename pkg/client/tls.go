@@ -0,0 +1,45 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrorInvalidCABundle is returned, when file of CAs has no PEM encoded
+// certificates.
+var ErrorInvalidCABundle = errors.New("no certificates in CA bundle")
+
+// TLSConfig returns TLS config of Config by files. Server is verified
+// by CAs of caFile, or by system CAs, if it is empty. If certFile and
+// keyFile are set, client presents its certificate.
+func TLSConfig(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%w: %s", ErrorInvalidCABundle, caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSConfig(t *testing.T) {
+	cfg, err := TLSConfig("", "", "")
+	require.NoError(t, err)
+	assert.Nil(t, cfg.RootCAs)
+	assert.Empty(t, cfg.Certificates)
+
+	ca := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(ca, []byte("not a certificate"), 0600))
+
+	_, err = TLSConfig(ca, "", "")
+	assert.ErrorIs(t, err, ErrorInvalidCABundle)
+
+	_, err = TLSConfig("", "client.crt", "")
+	assert.Error(t, err)
+}